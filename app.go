@@ -2,35 +2,204 @@ package cligo
 
 import (
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
 	"strings"
+	"text/template"
 
 	"golang.org/x/exp/slices"
 )
 
 type AppOption func(options *App)
 
+// WithErrorOnHelp causes parsing to return ErrHelpRequested (or ErrVersionRequested) instead of
+// exiting the application after the help message (or version) is printed.
 func WithErrorOnHelp() AppOption {
 	return func(app *App) {
 		app.returnErrorOnHelp = true
 	}
 }
 
+// WithDescription sets a summary paragraph which is printed after the usage line of the help message.
+func WithDescription(description string) AppOption {
+	return func(app *App) {
+		app.description = description
+	}
+}
+
+// WithEpilog sets a paragraph which is printed at the end of the help message.
+func WithEpilog(epilog string) AppOption {
+	return func(app *App) {
+		app.epilog = epilog
+	}
+}
+
+// WithAuthors sets the authors of the application, which are listed in the help message before
+// the epilog. Each author is typically a name followed by an email address, for example
+// "Jane Doe <jane@example.com>".
+func WithAuthors(authors ...string) AppOption {
+	return func(app *App) {
+		app.authors = append(app.authors, authors...)
+	}
+}
+
+// WithArgsEnv causes the application to read extra arguments from the environment variable name,
+// in the same way as GREP_OPTIONS or JAVA_TOOL_OPTIONS. The value is split following the quoting
+// rules of the POSIX shell (see SplitCommandLine) and the arguments are placed before those on
+// the command line, so that the command line can override them. Errors caused by these arguments
+// name the environment variable.
+func WithArgsEnv(name string) AppOption {
+	return func(app *App) {
+		app.argsEnv = name
+	}
+}
+
+// WithPosixlyCorrect causes options to end at the first positional argument, as required by
+// POSIX, rather than allowing options and positional arguments to be mixed. This is useful for
+// wrapper tools, where the arguments following the wrapped command belong to it. Setting the
+// POSIXLY_CORRECT environment variable has the same effect.
+func WithPosixlyCorrect() AppOption {
+	return func(app *App) {
+		app.posixlyCorrect = true
+	}
+}
+
+// WithAllowUnknown causes unknown options to be collected rather than treated as an error, so
+// that they can be forwarded to another program. Unknown returns the collected options in the
+// order they were given. Since the parser cannot know whether an unknown option takes a value,
+// only attached values, as in --foo=bar or -xVALUE, are collected with the option.
+//
+// The argument following an unknown option is parsed normally, so forwarding "--foo bar" loses
+// the pairing: Unknown returns only "--foo", and "bar" becomes a positional argument. Use
+// WithUnknownValues to collect such values along with their options.
+func WithAllowUnknown() AppOption {
+	return func(app *App) {
+		app.allowUnknown = true
+	}
+}
+
+// WithUnknownValues is like WithAllowUnknown, but an unknown option without an attached value,
+// such as --foo or -x, is assumed to take the following argument as its likely value unless
+// that argument looks like an option, so "--foo bar" is collected as a pair. This is a guess,
+// so a positional argument following an unknown flag is collected along with it.
+func WithUnknownValues() AppOption {
+	return func(app *App) {
+		app.allowUnknown = true
+		app.unknownValues = true
+	}
+}
+
+// WithSingleDashLong allows long options to be given with a single dash, as in -verbose or
+// -file=name, in the style of the flag package. An argument such as -abc is parsed as the long
+// option --abc if there is one, and as the short options -a -b -c otherwise. If there is a long
+// option --abc and -a, -b and -c are all flags, the argument is ambiguous and is an error.
+// The built-in options are also accepted as -help and, when a version is set, -version.
+func WithSingleDashLong() AppOption {
+	return func(app *App) {
+		app.singleDashLong = true
+	}
+}
+
+// WithStdout sets the writer that usage and other informational output is written to.
+// The default is os.Stdout.
+func WithStdout(w io.Writer) AppOption {
+	return func(app *App) {
+		app.stdout = w
+	}
+}
+
+// WithStderr sets the writer that error output is written to.
+// The default is os.Stderr.
+func WithStderr(w io.Writer) AppOption {
+	return func(app *App) {
+		app.stderr = w
+	}
+}
+
+// WithExitFunc sets the function called when the application wants to terminate the
+// process, for example after printing the help message. The default is os.Exit.
+//
+// If the function returns, parsing is stopped and the reason is reported as an error
+// (for example ErrHelpRequested), just as if WithErrorOnHelp had been set.
+func WithExitFunc(f func(int)) AppOption {
+	return func(app *App) {
+		app.exitFunc = f
+	}
+}
+
+// WithProgramName sets the program name used in the usage string.
+// The default is os.Args[0].
+func WithProgramName(name string) AppOption {
+	return func(app *App) {
+		app.programName = name
+	}
+}
+
 type UsageFunc func()
 
+type example struct {
+	args        string
+	explanation string
+}
+
 // An App serves as the main state for a cligo argument parser
 type App struct {
 	options           []*Option
 	groups            map[string][]*Option
 	usageFunc         UsageFunc
 	returnErrorOnHelp bool
+	stdout            io.Writer
+	stderr            io.Writer
+	exitFunc          func(int)
+	programName       string
+	version           string
+	description       string
+	epilog            string
+	authors           []string
+	examples          []example
+	usageTemplate     *template.Template
+	precedence        []SourceKind
+	lookupEnv         func(string) (string, bool)
+	config            []configEntry
+	configFiles       []string
+	parsedConfigFiles []string
+	configName        string
+	dotenv            map[string]dotenvValue
+	dotenvPaths       []string
+	parsedDotEnv      map[string]dotenvValue
+	responseFiles     bool
+	argsEnv           string
+	posixlyCorrect    bool
+	passthrough       *[]string
+	passthroughName   string
+	allowUnknown      bool
+	unknownValues     bool
+	unknown           []string
+	singleDashLong    bool
 }
 
 // NewApp returns a new instance of the App type
 func NewApp(opts ...AppOption) *App {
 	app := &App{
-		groups: make(map[string][]*Option),
+		groups:   make(map[string][]*Option),
+		stdout:   os.Stdout,
+		stderr:   os.Stderr,
+		exitFunc: os.Exit,
+		precedence: []SourceKind{
+			SourceArgv,
+			SourceEnv,
+			SourceConfig,
+			SourceDefault,
+		},
+		lookupEnv: os.LookupEnv,
+		dotenv:    make(map[string]dotenvValue),
+	}
+
+	if len(os.Args) != 0 {
+		app.programName = os.Args[0]
 	}
 
 	for _, opt := range opts {
@@ -47,56 +216,173 @@ func (a *App) SetUsageFunc(f UsageFunc) {
 	a.usageFunc = f
 }
 
-// Usage prints the usage string for the application
-func (a App) Usage() {
+// AddExample adds an example invocation of the application to the help message.
+// args is the command line without the program name, and explanation describes what it does.
+// Examples can be checked against the application's options with VerifyExamples.
+func (a *App) AddExample(args string, explanation string) {
+	a.examples = append(a.examples, example{args: args, explanation: explanation})
+}
 
-	if a.usageFunc != nil {
-		a.usageFunc()
-		return
-	}
+// VerifyExamples parses each example added with AddExample and returns an error describing
+// every example which fails to parse. This is intended to be called from a test so that
+// examples can't silently become out of date when options are renamed or removed.
+//
+// Examples are parsed against a copy of the application, so bound variables are not modified
+// and triggers are not called. Validators are not run, since they often depend on the
+// environment, such as the existence of files. For the same reason, the examples are parsed
+// without environment variables, .env files, configuration files or response files.
+func (a *App) VerifyExamples() error {
+	var errs []error
+	for _, ex := range a.examples {
+		app := a.dryRunCopy()
+		args, err := SplitCommandLine(ex.args)
+		if err == nil {
+			_, err = app.parseArgs(args)
+		}
 
-	fmt.Printf("Usage: %s [OPTIONS]", os.Args[0])
+		if err != nil && !isInformational(err) {
+			errs = append(errs, fmt.Errorf("example '%s': %w", ex.args, err))
+		}
+	}
 
-	positionals := filterFunc(a.options, func(opt *Option) bool {
-		return opt.IsPositional()
-	})
+	return errors.Join(errs...)
+}
 
-	for _, opt := range positionals {
-		fmt.Printf(" %s", opt.pName)
+// dryRunCopy returns a copy of the application whose options are bound to fresh variables
+// and have no triggers or validators, so that it can be parsed without side effects.
+func (a App) dryRunCopy() *App {
+	app := a
+	app.options = nil
+	app.groups = make(map[string][]*Option)
+	app.stdout = io.Discard
+	app.returnErrorOnHelp = true
+	app.configName = ""
+	app.config = nil
+	app.configFiles = nil
+	app.parsedConfigFiles = nil
+	app.argsEnv = ""
+	app.dotenv = make(map[string]dotenvValue)
+	app.dotenvPaths = nil
+	app.parsedDotEnv = nil
+	app.responseFiles = false
+	app.lookupEnv = func(string) (string, bool) { return "", false }
+	if a.passthrough != nil {
+		app.passthrough = new([]string)
 	}
 
-	fmt.Println("")
-	if len(positionals) != 0 {
-		fmt.Println("")
-		fmt.Println("Positionals:")
-		for _, opt := range positionals {
-			fmt.Println(opt.formatPositional())
+	copies := make(map[*Option]*Option, len(a.options))
+	for _, opt := range a.options {
+		c := *opt
+		c.owner = &app
+		c.count = 0
+		c.onSet = nil
+		c.validators = nil
+		c.isConfig = false
+		c.isProfile = false
+		switch opt.ptr.(type) {
+		case nil:
+		case flag.Value:
+			// there is no general way to copy a flag.Value, so its values are not checked
+			c.ptr = nil
+		default:
+			c.ptr = reflect.New(reflect.TypeOf(opt.ptr).Elem()).Interface()
 		}
+
+		copies[opt] = &c
+		app.options = append(app.options, &c)
+		app.groups[c.group] = append(app.groups[c.group], &c)
 	}
 
-	for groupName, group := range a.groups {
-		fmt.Println("")
-		fmt.Printf("%s:\n", groupName)
+	for _, opt := range app.options {
+		opt.needs = remapOptions(opt.needs, copies)
+		opt.excludes = remapOptions(opt.excludes, copies)
+	}
 
-		if groupName == "Options" {
-			fmt.Printf("  %-30s %s\n", "-h,--help", "Print this help message and exit")
+	return &app
+}
+
+func remapOptions(options []*Option, copies map[*Option]*Option) []*Option {
+	result := make([]*Option, 0, len(options))
+	for _, opt := range options {
+		result = append(result, copies[opt])
+	}
+	return result
+}
+
+// PreParse marks the named options (see Lookup for the accepted forms of names) to be
+// resolved before all other options. Triggers on these options are called, and their bound
+// variables are set, before the remaining options are resolved. Each option is still only
+// resolved once, so triggers are not called twice.
+//
+// PreParse panics if a name does not refer to an existing option.
+func (a *App) PreParse(names ...string) {
+	for _, name := range names {
+		opt, exists := a.Lookup(name)
+		if !exists {
+			panic(fmt.Sprintf("PreParse: unknown option '%s'", name))
 		}
+		opt.preParse = true
+	}
+}
 
-		for _, opt := range group {
-			if !opt.IsPositionalOnly() {
-				fmt.Println(opt.format())
-			}
+// Options returns all of the application's options, including positionals, in the
+// order they were added.
+func (a App) Options() []*Option {
+	return slices.Clone(a.options)
+}
+
+// Lookup finds an option by name. The name may be given with its leading dashes, for example
+// "--file" or "-f", or without them, in which case it matches a long or positional name.
+func (a App) Lookup(name string) (*Option, bool) {
+	switch {
+	case strings.HasPrefix(name, "--"):
+		opt, _, exists := a.findLongOption(name[2:])
+		return opt, exists
+	case strings.HasPrefix(name, "-"):
+		opt, _, exists := a.findShortOption(name[1:])
+		return opt, exists
+	}
+
+	if opt, _, exists := a.findLongOption(name); exists {
+		return opt, true
+	}
+
+	return a.findPositional(name)
+}
+
+func (a App) findPositional(name string) (*Option, bool) {
+	for _, opt := range a.options {
+		if opt.pName == name {
+			return opt, true
 		}
 	}
+
+	return nil, false
+}
+
+// Stdout returns the writer that usage and other informational output is written to.
+// Custom usage functions should write to this rather than os.Stdout.
+func (a App) Stdout() io.Writer {
+	return a.stdout
 }
 
-func setOption(ptr any, v string, isNegated bool) error {
+// Stderr returns the writer that error output is written to.
+func (a App) Stderr() io.Writer {
+	return a.stderr
+}
+
+// ProgramName returns the program name used in the usage string.
+func (a App) ProgramName() string {
+	return a.programName
+}
+
+func setOption(ptr any, values []string, isNegated bool) error {
 	// NOTE(eteran): isNegated is here for consistency of function definition,
 	// but only flags can be negated
 	_ = isNegated
 
 	if ptr != nil {
-		if err := setValue(ptr, v); err != nil {
+		if err := setValues(ptr, values); err != nil {
 			return err
 		}
 	}
@@ -159,6 +445,23 @@ func (a *App) AddFlag(name string, ptr any, help string, modifiers ...Modifier)
 	return opt
 }
 
+// Unknown returns the unknown options and their values found by the last parse, when the
+// application was created with WithAllowUnknown. For example, given "--foo=bar -x 1 -yz",
+// Unknown returns []string{"--foo=bar", "-x", "-yz"}, or []string{"--foo=bar", "-x", "1", "-yz"}
+// with WithUnknownValues.
+func (a App) Unknown() []string {
+	return slices.Clone(a.unknown)
+}
+
+// AddPassthrough causes the arguments following "--" to be stored in ptr rather than being
+// assigned to positional arguments or returned by ParseArgs. This is useful for wrapper tools,
+// for example "tool run -- cmd args". name is displayed in the usage string as [-- name...].
+// If "--" is not given, ptr is set to nil.
+func (a *App) AddPassthrough(name string, ptr *[]string) {
+	a.passthroughName = name
+	a.passthrough = ptr
+}
+
 func (a App) findLongOption(name string) (opt *Option, isNegated bool, exists bool) {
 
 	for _, opt := range a.options {
@@ -189,48 +492,6 @@ func (a App) findLongOption(name string) (opt *Option, isNegated bool, exists bo
 	return nil, false, false
 }
 
-func (a App) parseOneLong(arg string, args []string) ([]string, error) {
-	/*
-		--file filename (space)
-		--file=filename (equals)
-		--long_flag=true (long flag with equals to override default value)
-		--long (long flag)
-	*/
-	name := arg[2:]
-	param := ""
-
-	if strings.Contains(name, "=") {
-		parts := strings.SplitN(name, "=", 2)
-		name = parts[0]
-		param = parts[1]
-	}
-
-	opt, isNegated, exists := a.findLongOption(name)
-	if !exists {
-		return nil, fmt.Errorf("the following argument was not expected: %s\n%s", arg, ErrorSuffix)
-	}
-
-	if opt.isFlag {
-		if err := opt.setter(opt, param, isNegated); err != nil {
-			return nil, err
-		}
-	} else {
-		if param == "" {
-			if len(args) == 0 {
-				return nil, ErrMissingParameter
-			}
-			param = args[0]
-			args = args[1:]
-		}
-
-		if err := opt.setter(opt, param, isNegated); err != nil {
-			return nil, err
-		}
-	}
-
-	return args, nil
-}
-
 func (a App) findShortOption(name string) (opt *Option, isNegated bool, exists bool) {
 
 	for _, opt := range a.options {
@@ -261,177 +522,211 @@ func (a App) findShortOption(name string) (opt *Option, isNegated bool, exists b
 	return nil, false, false
 }
 
-func (a App) parseOneShort(arg string, args []string) ([]string, error) {
-	/*
-		-a (flag)
-		-f filename (option)
-		-ffilename (no space required)
-		-abc (flags can be combined)
-		-abcf filename (flags and option can be combined)
-	*/
-
-	name := arg[1:]
+// isFlagCluster returns true if name is a combination of short flags, such as "abc" for -abc
+func (a App) isFlagCluster(name string) bool {
+	for _, ch := range name {
+		opt, _, exists := a.findShortOption(string(ch))
+		if !exists || !opt.isFlag {
+			return false
+		}
+	}
 
-	for i, ch := range name {
-		shortName := string(ch)
+	return name != ""
+}
 
-		opt, isNegated, exists := a.findShortOption(shortName)
-		if !exists {
-			return nil, fmt.Errorf("the following argument was not expected: %s\n%s", arg, ErrorSuffix)
+// hasDigitOption returns true if any option has a short name which is a digit, in which case
+// arguments such as -5 are options rather than negative numbers
+func (a App) hasDigitOption() bool {
+	for _, opt := range a.options {
+		for _, name := range append(slices.Clip(opt.sNames), opt.sNamesNeg...) {
+			if name != "" && name[0] >= '0' && name[0] <= '9' {
+				return true
+			}
 		}
+	}
 
-		isLast := i == len(name)-1
-		if opt.isFlag {
-			if err := opt.setter(opt, "", isNegated); err != nil {
-				return nil, err
-			}
-		} else if isLast {
-			if len(args) == 0 {
-				return nil, ErrMissingParameter
-			}
+	return false
+}
 
-			param := args[0]
-			args = args[1:]
+// ParseStrict will parse os.Args strictly. This means that unexpected positional arguments
+// are considered an error. It equivalent to calling:
+//
+//	ParseArgsStrict(os.Args[1:])
+func (a *App) ParseStrict() error {
+	return a.ParseArgsStrict(os.Args[1:])
+}
 
-			if err := opt.setter(opt, param, isNegated); err != nil {
-				return nil, err
-			}
-		} else {
-			param := name[1+i:]
-			if err := opt.setter(opt, param, isNegated); err != nil {
-				return nil, err
-			}
-			break
-		}
+// ParseArgsStrict will parse the string slice args strictly.
+// This means that unexpected positional arguments are considered an error.
+func (a *App) ParseArgsStrict(args []string) error {
+	return a.handleHelp(a.parseArgsStrict(args))
+}
+
+// ParseStrict will parse os.Args.
+// It equivalent to calling:
+//
+//	ParseArgs(os.Args[1:])
+func (a *App) Parse() ([]string, error) {
+	return a.ParseArgs(os.Args[1:])
+}
+
+// ParseString splits cmdline into arguments following the quoting rules of the POSIX shell
+// (see SplitCommandLine) and parses them as if by ParseArgs. cmdline should not include the
+// program name.
+func (a *App) ParseString(cmdline string) ([]string, error) {
+	args, err := SplitCommandLine(cmdline)
+	if err != nil {
+		return nil, &UsageError{Err: err}
 	}
 
-	return args, nil
+	return a.ParseArgs(args)
 }
 
-func (a App) parseOne(args []string) ([]string, error) {
-	arg := args[0]
+// ParseArgs will parse the string slice args and returns the unprocessed args as a new slice.
+func (a *App) ParseArgs(args []string) ([]string, error) {
+	rest, err := a.parseArgs(args)
+	if err != nil {
+		return nil, a.handleHelp(err)
+	}
 
-	var err error
-	switch {
-	case arg == "-h" || arg == "--help":
-		a.Usage()
-		return args, ErrHelpRequested
-	case arg == "--":
-		args = args[1:]
-		return args, ErrEndOfArguments
-	case strings.HasPrefix(arg, "--"):
-		args = args[1:]
-		args, err = a.parseOneLong(arg, args)
-		if err != nil {
-			return nil, err
-		}
-	case strings.HasPrefix(arg, "-"):
-		args = args[1:]
-		args, err = a.parseOneShort(arg, args)
-		if err != nil {
-			return nil, err
-		}
-	default:
-		return args, ErrEndOfArguments
+	return rest, nil
+}
+
+// handleHelp terminates the application if err indicates that help or the version was requested,
+// unless the application was configured to return an error instead.
+func (a App) handleHelp(err error) error {
+	if isInformational(err) && !a.returnErrorOnHelp {
+		a.exitFunc(0)
+
+		// a custom exit function may return, in which case we must still stop parsing
 	}
 
-	return args, nil
+	return err
 }
 
-func (a App) parsePositional(args []string) ([]string, error) {
+func (a *App) parseArgsStrict(args []string) error {
+	rest, err := a.parseArgs(args)
+	if err != nil {
+		return err
+	}
 
+	if len(rest) != 0 {
+		return &UsageError{Err: fmt.Errorf("the following arguments were not expected: %s\n%s", rest, ErrorSuffix)}
+	}
+
+	return nil
+}
+
+func (a *App) parseArgs(args []string) ([]string, error) {
+	// Exists, Count and Source reflect only the current parse
 	for _, opt := range a.options {
+		opt.isSet = false
+		opt.count = 0
+		opt.source = Source{}
+	}
 
-		if !opt.IsPositional() {
-			continue
-		}
+	if err := a.loadDotEnvs(); err != nil {
+		return nil, &UsageError{Err: err}
+	}
 
-		if opt.Exists() {
-			continue
-		}
+	args, origins, err := a.expandArgs(args)
+	if err != nil {
+		return nil, &UsageError{Err: err}
+	}
 
-		if len(args) == 0 {
-			break
-		}
+	p := &parser{app: a, total: len(args), origins: origins}
 
-		if err := opt.setter(opt, args[0], false); err != nil {
+	rest, err := p.parse(args)
+	a.unknown = p.unknown
+	if err != nil {
+		if isInformational(err) {
 			return nil, err
 		}
 
-		args = args[1:]
+		return nil, &UsageError{Err: err}
 	}
 
-	return args, nil
-}
+	if a.passthrough != nil {
+		*a.passthrough = p.passthrough
+	}
 
-// ParseStrict will parse os.Args strictly. This means that unexpected positional arguments
-// are considered an error. It equivalent to calling:
-//
-//	ParseArgsStrict(os.Args[1:])
-func (a App) ParseStrict() error {
-	return a.ParseArgsStrict(os.Args[1:])
-}
+	// options marked with PreParse are resolved first so that they can affect how the
+	// rest of the options are resolved, for example by loading a configuration file
+	if err := p.resolve(func(opt *Option) bool { return opt.preParse }); err != nil {
+		return nil, &UsageError{Err: err}
+	}
 
-// ParseArgsStrict will parse the string slice args strictly.
-// This means that unexpected positional arguments are considered an error.
-func (a App) ParseArgsStrict(args []string) error {
-	rest, err := a.ParseArgs(args)
-	if err != nil {
-		return err
+	if err := p.loadConfigs(); err != nil {
+		return nil, &UsageError{Err: err}
 	}
 
-	if len(rest) != 0 {
-		return fmt.Errorf("the following arguments were not expected: %s\n%s", rest, ErrorSuffix)
+	if err := p.resolve(func(opt *Option) bool { return !opt.preParse }); err != nil {
+		return nil, &UsageError{Err: err}
 	}
 
-	return nil
-}
+	if err := a.validateOptions(); err != nil {
+		return nil, &UsageError{Err: err}
+	}
 
-// ParseStrict will parse os.Args.
-// It equivalent to calling:
-//
-//	ParseArgs(os.Args[1:])
-func (a App) Parse() ([]string, error) {
-	return a.ParseArgs(os.Args[1:])
+	return rest, nil
 }
 
-// ParseArgs will parse the string slice args and returns the unprocessed args as a new slice.
-func (a App) ParseArgs(args []string) ([]string, error) {
-	var err error
-
-	for len(args) > 0 {
-		args, err = a.parseOne(args)
-		if err != nil {
-			if errors.Is(err, ErrEndOfArguments) {
-				break
+// expandArgs adds the arguments from the environment variable set with WithArgsEnv to args and
+// expands response files, returning the resulting arguments along with the source of each one
+func (a *App) expandArgs(args []string) ([]string, []Source, error) {
+	var origins []Source
+
+	if a.argsEnv != "" {
+		if value, _, ok := a.getenv(a.argsEnv); ok {
+			envArgs, err := SplitCommandLine(value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("environment variable %s: %w", a.argsEnv, err)
 			}
 
-			if errors.Is(err, ErrHelpRequested) {
-				if a.returnErrorOnHelp {
-					return nil, err
-				}
-				os.Exit(0)
+			for range envArgs {
+				origins = append(origins, Source{Kind: SourceArgv, Name: a.argsEnv})
 			}
 
-			return nil, err
+			args = append(envArgs, args...)
 		}
 	}
 
-	args, err = a.parsePositional(args)
-	if err != nil {
-		return nil, err
+	for len(origins) < len(args) {
+		origins = append(origins, Source{Kind: SourceArgv})
 	}
 
-	if err := a.validateOptions(); err != nil {
-		return nil, err
+	if a.responseFiles {
+		return expandResponseFiles(args, origins)
+	}
+
+	return args, origins, nil
+}
+
+// isPosixlyCorrect returns true if options end at the first positional argument
+func (a App) isPosixlyCorrect() bool {
+	if a.posixlyCorrect {
+		return true
 	}
 
-	return args, nil
+	_, ok := a.lookupEnv("POSIXLY_CORRECT")
+	return ok
+}
+
+// isInformational returns true if err indicates that parsing stopped because
+// the user asked for information such as the help message or version.
+func isInformational(err error) bool {
+	return errors.Is(err, ErrHelpRequested) || errors.Is(err, ErrVersionRequested)
 }
 
 func (a App) validateOptions() error {
 	for _, opt := range a.options {
-		if opt.isRequired && !opt.Exists() {
+		// a default value provides the value of a required option which was not given
+		isMissing := !opt.Exists() && !opt.hasDefault
+		if opt.isRequired && isMissing {
+			return fmt.Errorf("%s is required\n%s", opt.canonicalName(), ErrorSuffix)
+		}
+
+		if opt.IsPositional() && opt.hasArity() && opt.arityMin > 0 && isMissing {
 			return fmt.Errorf("%s is required\n%s", opt.canonicalName(), ErrorSuffix)
 		}
 
@@ -456,12 +751,17 @@ func (a App) validateOptions() error {
 func pointerType(ptr any) string {
 	switch ptr.(type) {
 	case *int, *int8, *int16, *int32, *int64, *uint, *uint8, *uint16, *uint32, *uint64:
-		return " NUMBER"
+		return "NUMBER"
 	case *float32, *float64:
-		return " REAL"
+		return "REAL"
 	case *string:
-		return " TEXT"
+		return "TEXT"
 	default:
+		// slices and arrays use the placeholder of their elements
+		rv := reflect.ValueOf(ptr)
+		if rv.Kind() == reflect.Ptr && (rv.Elem().Kind() == reflect.Slice || rv.Elem().Kind() == reflect.Array) {
+			return pointerType(reflect.New(rv.Elem().Type().Elem()).Interface())
+		}
 		return ""
 	}
 }
@@ -1,6 +1,12 @@
 package cligo_test
 
 import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/eteran/cligo"
@@ -620,3 +626,1606 @@ func TestNonExistingFileValidatorErrorDir(t *testing.T) {
 	err := app.ParseArgsStrict(args)
 	require.Error(t, err)
 }
+
+func TestHelpExitFunc(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	exitCode := -1
+
+	app := cligo.NewApp(
+		cligo.WithStdout(&stdout),
+		cligo.WithExitFunc(func(code int) { exitCode = code }),
+		cligo.WithProgramName("my_app"),
+	)
+
+	option1 := ""
+	app.AddOption("-a,--alpha", &option1, "Option1")
+
+	args := []string{"--help", "--alpha=hello"}
+	_, err := app.ParseArgs(args)
+	require.ErrorIs(t, err, cligo.ErrHelpRequested)
+	require.Equal(t, 0, exitCode)
+	require.Equal(t, "", option1)
+	require.Contains(t, stdout.String(), "Usage: my_app [OPTIONS]")
+	require.Contains(t, stdout.String(), "--alpha")
+}
+
+func TestUsageWriter(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	app := cligo.NewApp(cligo.WithStdout(&stdout), cligo.WithProgramName("my_app"))
+
+	var filename string
+	app.AddOption("filename", &filename, "filename")
+
+	app.Usage()
+	require.Contains(t, stdout.String(), "Usage: my_app [OPTIONS] filename")
+}
+
+func TestExitCodeUsage(t *testing.T) {
+	t.Parallel()
+
+	var stderr bytes.Buffer
+	app := cligo.NewApp(cligo.WithStderr(&stderr))
+
+	option1 := ""
+	app.AddOption("-a,--alpha", &option1, "Option1")
+
+	err := app.ParseArgsStrict([]string{"--beta"})
+	require.Error(t, err)
+	require.Equal(t, cligo.ExitUsage, app.Exit(err))
+	require.Contains(t, stderr.String(), "--beta")
+}
+
+func TestExitCodeHelp(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	app := cligo.NewApp(cligo.WithErrorOnHelp(), cligo.WithStdout(&stdout))
+
+	err := app.ParseArgsStrict([]string{"--help"})
+	require.ErrorIs(t, err, cligo.ErrHelpRequested)
+	require.Equal(t, cligo.ExitSuccess, app.Exit(err))
+}
+
+func TestExitCodeApplication(t *testing.T) {
+	t.Parallel()
+
+	var stderr bytes.Buffer
+	app := cligo.NewApp(cligo.WithStderr(&stderr))
+
+	require.Equal(t, cligo.ExitSuccess, app.Exit(nil))
+	require.Equal(t, cligo.ExitFailure, app.Exit(errors.New("failed")))
+	require.Equal(t, 3, app.Exit(fmt.Errorf("wrapped: %w", cligo.NewExitError(errors.New("failed"), 3))))
+	require.Equal(t, "failed\nwrapped: failed\n", stderr.String())
+}
+
+func TestVersion(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	exitCode := -1
+
+	app := cligo.NewApp(
+		cligo.WithVersion("1.2.3"),
+		cligo.WithStdout(&stdout),
+		cligo.WithExitFunc(func(code int) { exitCode = code }),
+		cligo.WithProgramName("my_app"),
+	)
+
+	err := app.ParseArgsStrict([]string{"-V"})
+	require.ErrorIs(t, err, cligo.ErrVersionRequested)
+	require.Equal(t, 0, exitCode)
+	require.Equal(t, cligo.ExitSuccess, app.Exit(err))
+	require.Equal(t, "my_app 1.2.3\n", stdout.String())
+}
+
+func TestVersionInUsage(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	app := cligo.NewApp(cligo.WithVersion("1.2.3"), cligo.WithStdout(&stdout), cligo.WithProgramName("my_app"))
+
+	var verbose bool
+	app.AddFlag("-v,--verbose", &verbose, "increase verbosity")
+
+	app.Usage()
+	require.Contains(t, stdout.String(), "my_app 1.2.3\n")
+	require.Contains(t, stdout.String(), "-V,--version")
+}
+
+func TestVersionNotRegistered(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp()
+
+	err := app.ParseArgsStrict([]string{"--version"})
+	require.Error(t, err)
+	require.NotErrorIs(t, err, cligo.ErrVersionRequested)
+}
+
+func TestVersionNameTakenByOption(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	app := cligo.NewApp(cligo.WithVersion("1.2.3"), cligo.WithStdout(&stdout), cligo.WithErrorOnHelp(), cligo.WithProgramName("my_app"))
+
+	var verbose bool
+	app.AddFlag("-V,--verbose", &verbose, "increase verbosity")
+
+	_, err := app.ParseArgs([]string{"-V"})
+	require.NoError(t, err)
+	require.True(t, verbose)
+	require.Empty(t, stdout.String())
+
+	app.Usage()
+	require.Contains(t, stdout.String(), "--version")
+	require.NotContains(t, stdout.String(), "-V,--version")
+
+	stdout.Reset()
+	_, err = app.ParseArgs([]string{"--version"})
+	require.ErrorIs(t, err, cligo.ErrVersionRequested)
+	require.Equal(t, "my_app 1.2.3\n", stdout.String())
+}
+
+func TestBuildInfoVersion(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp(cligo.WithBuildInfoVersion())
+	require.NotEmpty(t, app.Version())
+}
+
+func TestUsageMetadata(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	app := cligo.NewApp(
+		cligo.WithStdout(&stdout),
+		cligo.WithProgramName("my_app"),
+		cligo.WithDescription("Does something useful."),
+		cligo.WithEpilog("Report bugs to the issue tracker."),
+		cligo.WithAuthors("Jane Doe <jane@example.com>", "John Doe"),
+	)
+
+	var filename string
+	app.AddOption("-f,--file", &filename, "filename")
+	app.AddExample("-f input.txt", "process input.txt")
+
+	app.Usage()
+	require.Contains(t, stdout.String(), "\nDoes something useful.\n")
+	require.Contains(t, stdout.String(), "Examples:\n  my_app -f input.txt\n      process input.txt\n")
+	require.Contains(t, stdout.String(), "\nAuthors:\n  Jane Doe <jane@example.com>\n  John Doe\n\nReport bugs to the issue tracker.\n")
+}
+
+func TestRequiredWithDefault(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp()
+
+	var port int
+	opt := app.AddOption("-p,--port", &port, "port", cligo.Required(), cligo.Default("80"))
+
+	_, err := app.ParseArgs([]string{})
+	require.NoError(t, err)
+	require.Equal(t, 80, port)
+	require.False(t, opt.Exists())
+
+	_, err = app.ParseArgs([]string{"--port", "8080"})
+	require.NoError(t, err)
+	require.Equal(t, 8080, port)
+}
+
+func TestVerifyExamples(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp()
+
+	var filename string
+	var verbose bool
+	app.AddOption("-f,--file", &filename, "filename", cligo.Required(), cligo.AddValidator(cligo.ExistingFile()))
+	app.AddFlag("-v,--verbose", &verbose, "increase verbosity")
+
+	app.AddExample("-f input.txt", "process input.txt")
+	app.AddExample("-v --file=input.txt", "process input.txt verbosely")
+	require.NoError(t, app.VerifyExamples())
+	require.Equal(t, "", filename)
+	require.Equal(t, false, verbose)
+
+	app.AddExample("--filename input.txt", "out of date")
+	app.AddExample("-v", "missing required option")
+	err := app.VerifyExamples()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "--filename input.txt")
+	require.Contains(t, err.Error(), "file is required")
+}
+
+func TestVerifyExamplesIgnoresEnvironment(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{"APP_ARGS": "--bogus", "APP_FILE": "input.txt"}
+	app := cligo.NewApp(
+		cligo.WithArgsEnv("APP_ARGS"),
+		cligo.WithResponseFiles(),
+		cligo.WithLookupEnv(func(name string) (string, bool) {
+			value, ok := env[name]
+			return value, ok
+		}),
+	)
+
+	var filename string
+	app.AddOption("-f,--file", &filename, "filename", cligo.Required(), cligo.Env("APP_FILE"))
+
+	app.AddExample("-f input.txt", "process input.txt")
+	app.AddExample("-f input.txt @missing.rsp", "read more arguments from a response file")
+	require.NoError(t, app.VerifyExamples())
+
+	app.AddExample("", "missing required option")
+	err := app.VerifyExamples()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "file is required")
+
+	app = cligo.NewApp()
+	app.AddOption("-f,--file", &filename, "filename", cligo.Required())
+	require.NoError(t, app.LoadConfig(writeFile(t, "config", "file = input.txt\n")))
+	app.AddExample("", "missing required option")
+	require.ErrorContains(t, app.VerifyExamples(), "file is required")
+
+	app = cligo.NewApp(cligo.WithLookupEnv(func(string) (string, bool) { return "", false }))
+	app.AddOption("-f,--file", &filename, "filename", cligo.Required(), cligo.Env("APP_FILE"))
+	require.NoError(t, app.LoadDotEnv(writeFile(t, ".env", "APP_FILE=input.txt\n")))
+	app.AddExample("", "missing required option")
+	require.ErrorContains(t, app.VerifyExamples(), "file is required")
+}
+
+func TestUsageTemplate(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	app := cligo.NewApp(cligo.WithStdout(&stdout), cligo.WithProgramName("my_app"))
+
+	var filename string
+	var count int
+	app.AddOption("-f,--file", &filename, "the file to read", cligo.Required(), cligo.Metavar("PATH"))
+	app.AddFlag("-c,--count", &count, "a counter", cligo.Group("Other"))
+
+	err := app.SetUsageTemplate(`{{.Synopsis}}
+{{range .Groups}}{{.Name}}:
+{{range .Options}}{{pad 12 (index .Names 0)}}|{{.Metavar}}|{{.Required}}|{{.Help | wrap 8 | indent 2}}
+{{end}}{{end}}`)
+	require.NoError(t, err)
+
+	app.Usage()
+	require.Equal(t, `my_app [OPTIONS]
+Options:
+-h          ||false|  Print
+  this
+  help
+  message
+  and exit
+-f          |PATH|true|  the file
+  to read
+Other:
+-c          |NUMBER|false|  a
+  counter
+`, stdout.String())
+}
+
+func TestUsageTemplateError(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp()
+	require.Error(t, app.SetUsageTemplate("{{.Synopsis"))
+}
+
+func TestIntrospection(t *testing.T) {
+	t.Parallel()
+	app := cligo.NewApp()
+
+	var option1 string
+	var option2 int
+	var dest string
+
+	o1 := app.AddOption("-a,--alpha", &option1, "Option1", cligo.Required(), cligo.Group("First"))
+	o2 := app.AddFlag("-b,--beta,!--no-beta", &option2, "Option2", cligo.Needs(o1))
+	o3 := app.AddOption("dest", &dest, "dest", cligo.Excludes(o2))
+
+	require.Equal(t, []*cligo.Option{o1, o2, o3}, app.Options())
+
+	require.Equal(t, []string{"alpha"}, o1.LongNames())
+	require.Equal(t, []string{"a"}, o1.ShortNames())
+	require.Equal(t, "Option1", o1.Help())
+	require.Equal(t, "First", o1.Group())
+	require.True(t, o1.IsRequired())
+	require.False(t, o1.IsFlag())
+	require.Equal(t, "TEXT", o1.Metavar())
+
+	require.True(t, o2.IsFlag())
+	require.Equal(t, []string{"no-beta"}, o2.NegatedLongNames())
+	require.Equal(t, []*cligo.Option{o1}, o2.Needs())
+	require.Equal(t, []*cligo.Option{o3}, o2.Excludes())
+	require.Equal(t, "dest", o3.PositionalName())
+
+	for name, expected := range map[string]*cligo.Option{
+		"--alpha":   o1,
+		"-a":        o1,
+		"alpha":     o1,
+		"--no-beta": o2,
+		"dest":      o3,
+	} {
+		opt, ok := app.Lookup(name)
+		require.True(t, ok, name)
+		require.Same(t, expected, opt, name)
+	}
+
+	_, ok := app.Lookup("--gamma")
+	require.False(t, ok)
+}
+
+func TestSource(t *testing.T) {
+	t.Parallel()
+	app := cligo.NewApp()
+
+	var verbose int
+	var filename string
+	var dest string
+	port := 8080
+
+	o1 := app.AddFlag("-v,--verbose", &verbose, "increase verbosity")
+	o2 := app.AddOption("-f,--file", &filename, "filename")
+	o3 := app.AddOption("dest", &dest, "dest")
+	o4 := app.AddOption("-p,--port", &port, "port")
+
+	args := []string{"-v", "--file", "test.txt", "-v", "out.txt"}
+	err := app.ParseArgsStrict(args)
+	require.NoError(t, err)
+
+	require.Equal(t, cligo.Source{Kind: cligo.SourceArgv, Index: 3}, o1.Source())
+	require.Equal(t, cligo.Source{Kind: cligo.SourceArgv, Index: 1}, o2.Source())
+	require.Equal(t, cligo.Source{Kind: cligo.SourceArgv, Index: 4}, o3.Source())
+	require.Equal(t, cligo.SourceDefault, o4.Source().Kind)
+
+	report := app.Provenance()
+	require.Len(t, report, 4)
+	require.Equal(t, "--file=test.txt (argv[1])", report[1].String())
+	require.Equal(t, "--port=8080 (default)", report[3].String())
+}
+
+func TestSourceNotSetOnError(t *testing.T) {
+	t.Parallel()
+	app := cligo.NewApp()
+
+	var verbose bool
+	var port int
+	app.AddFlag("-v,--verbose", &verbose, "verbose")
+	opt := app.AddOption("-p,--port", &port, "port", cligo.AddValidator(cligo.Range(1, 65535)))
+
+	_, err := app.ParseArgs([]string{"--port", "8080"})
+	require.NoError(t, err)
+	require.Equal(t, cligo.Source{Kind: cligo.SourceArgv, Index: 0}, opt.Source())
+
+	_, err = app.ParseArgs([]string{"-v", "--port", "abc"})
+	require.Error(t, err)
+	require.Equal(t, cligo.Source{}, opt.Source())
+
+	_, err = app.ParseArgs([]string{"-v", "--port", "0"})
+	require.Error(t, err)
+	require.Equal(t, cligo.Source{}, opt.Source())
+}
+
+func TestReparse(t *testing.T) {
+	t.Parallel()
+	app := cligo.NewApp()
+
+	var user string
+	var a, b bool
+	oUser := app.AddOption("-u,--user", &user, "user", cligo.Required())
+	oA := app.AddFlag("-a", &a, "a")
+	oB := app.AddFlag("-b", &b, "b", cligo.Excludes(oA))
+
+	_, err := app.ParseString("--user bob -a")
+	require.NoError(t, err)
+	require.Equal(t, cligo.Source{Kind: cligo.SourceArgv, Index: 0}, oUser.Source())
+	require.Equal(t, 1, oA.Count())
+
+	_, err = app.ParseString("")
+	require.ErrorContains(t, err, "user is required")
+	require.Equal(t, cligo.Source{}, oUser.Source())
+	require.False(t, oA.Exists())
+
+	_, err = app.ParseString("--user x -b")
+	require.NoError(t, err)
+	require.Equal(t, 0, oA.Count())
+	require.Equal(t, 1, oB.Count())
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestPrecedence(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{"PORT": "9000", "USER_NAME": "env-user"}
+	app := cligo.NewApp(cligo.WithLookupEnv(func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}))
+
+	config := writeFile(t, "config", "# comment\nport = 7000\nhost = 'config-host'\nuser = config-user\n")
+	require.NoError(t, app.LoadConfig(config))
+
+	var port int
+	var host string
+	var user string
+	var level string
+	var verbose int
+
+	oPort := app.AddOption("-p,--port", &port, "port", cligo.Env("PORT"), cligo.Default("8080"))
+	oHost := app.AddOption("--host", &host, "host", cligo.Default("localhost"))
+	oUser := app.AddOption("--user", &user, "user", cligo.Env("USER_NAME"))
+	oLevel := app.AddOption("--level", &level, "level", cligo.Default("info"))
+	oVerbose := app.AddFlag("-v,--verbose", &verbose, "verbosity", cligo.Env("VERBOSE"))
+
+	err := app.ParseArgsStrict([]string{"--user=argv-user", "-vv"})
+	require.NoError(t, err)
+
+	require.Equal(t, 9000, port)
+	require.Equal(t, cligo.Source{Kind: cligo.SourceEnv, Name: "PORT"}, oPort.Source())
+	require.Equal(t, 1, oPort.Count())
+
+	require.Equal(t, "config-host", host)
+	require.Equal(t, cligo.Source{Kind: cligo.SourceConfig, Name: "host", File: config, Line: 3}, oHost.Source())
+
+	require.Equal(t, "argv-user", user)
+	require.Equal(t, 1, oUser.Count())
+	require.Equal(t, cligo.SourceArgv, oUser.Source().Kind)
+
+	require.Equal(t, "info", level)
+	require.False(t, oLevel.Exists())
+	require.Equal(t, cligo.SourceDefault, oLevel.Source().Kind)
+
+	require.Equal(t, 2, verbose)
+	require.Equal(t, 2, oVerbose.Count())
+}
+
+func TestPrecedenceCustom(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp(
+		cligo.WithPrecedence(cligo.SourceEnv, cligo.SourceArgv),
+		cligo.WithLookupEnv(func(name string) (string, bool) {
+			return "from-env", name == "NAME"
+		}),
+	)
+
+	var name string
+	var other string
+	app.AddOption("--name", &name, "name", cligo.Env("NAME"))
+	app.AddOption("--other", &other, "other", cligo.Default("unused"))
+
+	err := app.ParseArgsStrict([]string{"--name=from-argv"})
+	require.NoError(t, err)
+	require.Equal(t, "from-env", name)
+	require.Equal(t, "", other)
+}
+
+func TestConfigErrors(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp()
+	require.Error(t, app.LoadConfig(writeFile(t, "config", "port\n")))
+
+	config := writeFile(t, "config", "port = 10\n")
+	require.NoError(t, app.LoadConfig(config))
+
+	var port int
+	app.AddOption("--port", &port, "port", cligo.AddValidator(cligo.Range(100, 200)))
+
+	err := app.ParseArgsStrict(nil)
+	require.ErrorContains(t, err, config+":1: 10 is not in the range")
+
+	require.NoError(t, app.LoadConfig(writeFile(t, "config", "prot = 10\n")))
+	err = app.ParseArgsStrict([]string{"--port=150"})
+	require.ErrorContains(t, err, ":1: unknown option 'prot'")
+}
+
+func TestConfigOption(t *testing.T) {
+	t.Parallel()
+
+	config := writeFile(t, "config", "host = config-host\nport = 7000\n")
+
+	app := cligo.NewApp()
+
+	var configPath string
+	var host string
+	var port int
+
+	triggered := 0
+	oConfig := app.AddConfigOption("-c,--config", &configPath, "configuration file", cligo.Trigger(func(opt *cligo.Option) error {
+		triggered++
+		return nil
+	}))
+	app.AddOption("--host", &host, "host")
+	oPort := app.AddOption("--port", &port, "port")
+
+	err := app.ParseArgsStrict([]string{"--port=8000", "--config", config})
+	require.NoError(t, err)
+	require.Equal(t, config, configPath)
+	require.Equal(t, "config-host", host)
+	require.Equal(t, 8000, port)
+	require.Equal(t, 1, triggered)
+	require.Equal(t, 1, oConfig.Count())
+	require.Equal(t, 1, oPort.Count())
+}
+
+func TestConfigOptionMissing(t *testing.T) {
+	t.Parallel()
+
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	app := cligo.NewApp()
+	app.AddConfigOption("--config", nil, "configuration file", cligo.Default(missing))
+
+	err := app.ParseArgsStrict(nil)
+	require.NoError(t, err)
+
+	err = app.ParseArgsStrict([]string{"--config", missing})
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestPreParse(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp()
+
+	var profile string
+	var name string
+	var seen string
+
+	app.AddOption("--profile", &profile, "profile")
+	app.AddOption("--name", &name, "name", cligo.Trigger(func(opt *cligo.Option) error {
+		seen = profile
+		return nil
+	}))
+	app.PreParse("--profile")
+
+	err := app.ParseArgsStrict([]string{"--name=test", "--profile=prod"})
+	require.NoError(t, err)
+	require.Equal(t, "prod", seen)
+
+	require.Panics(t, func() { app.PreParse("--missing") })
+}
+
+func TestConfigProfile(t *testing.T) {
+	t.Parallel()
+
+	config := writeFile(t, "config", `
+host = default-host
+port = 7000
+
+[profile prod]
+host = prod-host
+
+[profile test]
+host = test-host
+`)
+
+	app := cligo.NewApp()
+	require.NoError(t, app.LoadConfig(config))
+
+	var host string
+	var port int
+	app.AddProfileOption("--profile", nil, "configuration profile")
+	oHost := app.AddOption("--host", &host, "host")
+	app.AddOption("--port", &port, "port")
+
+	err := app.ParseArgsStrict([]string{"--profile", "prod"})
+	require.NoError(t, err)
+	require.Equal(t, "prod-host", host)
+	require.Equal(t, 7000, port)
+	require.Equal(t, cligo.Source{Kind: cligo.SourceConfig, Name: "host", File: config, Line: 6}, oHost.Source())
+
+	err = app.ParseArgsStrict([]string{"--profile", "staging"})
+	require.ErrorContains(t, err, "profile 'staging'")
+}
+
+func TestConfigProfileFromConfigOption(t *testing.T) {
+	t.Parallel()
+
+	config := writeFile(t, "config", `
+host = default-host
+
+[profile prod]
+host = prod-host
+`)
+
+	app := cligo.NewApp()
+
+	var host string
+	app.AddConfigOption("--config", nil, "configuration file")
+	app.AddProfileOption("--profile", nil, "configuration profile")
+	app.AddOption("--host", &host, "host")
+
+	err := app.ParseArgsStrict([]string{"--config", config, "--profile", "prod"})
+	require.NoError(t, err)
+	require.Equal(t, "prod-host", host)
+
+	err = app.ParseArgsStrict([]string{"--config", config, "--profile", "staging"})
+	require.ErrorContains(t, err, "profile 'staging' was not found")
+}
+
+func TestConfigDiscovery(t *testing.T) {
+	t.Parallel()
+
+	home := t.TempDir()
+	xdg := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".config", "cligo-test"), 0o700))
+	require.NoError(t, os.MkdirAll(filepath.Join(xdg, "cligo-test"), 0o700))
+
+	homeConfig := filepath.Join(home, ".config", "cligo-test", "config")
+	xdgConfig := filepath.Join(xdg, "cligo-test", "config")
+	require.NoError(t, os.WriteFile(homeConfig, []byte("host = home-host\nport = 7000\n"), 0o600))
+	require.NoError(t, os.WriteFile(xdgConfig, []byte("host = xdg-host\n"), 0o600))
+
+	env := map[string]string{"HOME": home, "XDG_CONFIG_HOME": xdg}
+	app := cligo.NewApp(
+		cligo.WithConfigDiscovery("cligo-test"),
+		cligo.WithLookupEnv(func(name string) (string, bool) {
+			value, ok := env[name]
+			return value, ok
+		}),
+	)
+
+	require.Equal(t, []string{
+		"/etc/cligo-test/config",
+		homeConfig,
+		xdgConfig,
+		".cligo-test.conf",
+	}, app.ConfigSearchPaths())
+
+	var host string
+	var port int
+	app.AddOption("--host", &host, "host")
+	app.AddOption("--port", &port, "port")
+
+	err := app.ParseArgsStrict(nil)
+	require.NoError(t, err)
+	require.Equal(t, "xdg-host", host)
+	require.Equal(t, 7000, port)
+	require.Equal(t, []string{homeConfig, xdgConfig}, app.ConfigFiles())
+}
+
+func TestDotEnv(t *testing.T) {
+	t.Parallel()
+
+	dotenv := writeFile(t, ".env", `# database settings
+export DB_HOST=db.local   # inline comment
+DB_PORT = 5432
+DB_NAME='literal ${DB_HOST}'
+DB_URL="postgres://${DB_HOST}:$DB_PORT/app\tx"
+DB_USER=real-user
+MULTI="line one
+line two"
+`)
+
+	env := map[string]string{"DB_USER": "env-user"}
+	app := cligo.NewApp(cligo.WithLookupEnv(func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}))
+	require.NoError(t, app.LoadDotEnv(dotenv))
+
+	var host, name, url, user, multi string
+	var port int
+	oHost := app.AddOption("--host", &host, "host", cligo.Env("DB_HOST"))
+	app.AddOption("--port", &port, "port", cligo.Env("DB_PORT"))
+	app.AddOption("--name", &name, "name", cligo.Env("DB_NAME"))
+	app.AddOption("--url", &url, "url", cligo.Env("DB_URL"))
+	oUser := app.AddOption("--user", &user, "user", cligo.Env("DB_USER"))
+	app.AddOption("--multi", &multi, "multi", cligo.Env("MULTI"))
+
+	err := app.ParseArgsStrict(nil)
+	require.NoError(t, err)
+	require.Equal(t, "db.local", host)
+	require.Equal(t, 5432, port)
+	require.Equal(t, "literal ${DB_HOST}", name)
+	require.Equal(t, "postgres://db.local:5432/app\tx", url)
+	require.Equal(t, "env-user", user)
+	require.Equal(t, "line one\nline two", multi)
+
+	require.Equal(t, cligo.Source{Kind: cligo.SourceEnv, Name: "DB_HOST", File: dotenv, Line: 2}, oHost.Source())
+	require.Equal(t, cligo.Source{Kind: cligo.SourceEnv, Name: "DB_USER"}, oUser.Source())
+
+	_, ok := os.LookupEnv("DB_HOST")
+	require.False(t, ok)
+}
+
+func TestDotEnvOption(t *testing.T) {
+	t.Parallel()
+
+	dotenv := writeFile(t, ".env", "PORT=abc\n")
+	app := cligo.NewApp(
+		cligo.WithDotEnv(filepath.Join(t.TempDir(), "missing"), dotenv),
+		cligo.WithLookupEnv(func(name string) (string, bool) { return "", false }),
+	)
+
+	var port int
+	app.AddOption("--port", &port, "port", cligo.Env("PORT"))
+
+	err := app.ParseArgsStrict(nil)
+	require.ErrorContains(t, err, "environment variable PORT ("+dotenv+":1)")
+
+	app = cligo.NewApp()
+	require.Error(t, app.LoadDotEnv(writeFile(t, ".env", "NAME='unterminated\n")))
+	require.Error(t, app.LoadDotEnv(writeFile(t, ".env", "NAME=\"value\" trailing\n")))
+}
+
+func TestSecretMasked(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	app := cligo.NewApp(cligo.WithStdout(&stdout))
+
+	password := "hunter2"
+	opt := app.AddOption("--db-password", &password, "database password", cligo.CaptureDefault(), cligo.Secret())
+
+	app.Usage()
+	require.NotContains(t, stdout.String(), "hunter2")
+	require.Contains(t, stdout.String(), "--db-password,--db-password-file TEXT [********]")
+	require.Equal(t, "********", opt.DefaultString())
+
+	require.NoError(t, app.ParseArgsStrict([]string{"--db-password=letmein"}))
+	require.Equal(t, "letmein", password)
+	require.Equal(t, "--db-password=******** (argv[0])", app.Provenance()[0].String())
+}
+
+func TestSecretErrorsMasked(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp()
+
+	var pin int
+	var code int
+	app.AddOption("--pin", &pin, "pin", cligo.Secret())
+	app.AddOption("--code", &code, "code", cligo.Secret(), cligo.AddValidator(cligo.Range(0, 9999)))
+
+	_, err := app.ParseArgs([]string{"--pin", "hunter2"})
+	require.ErrorContains(t, err, "--pin: invalid value ********")
+	require.NotContains(t, err.Error(), "hunter2")
+
+	_, err = app.ParseArgs([]string{"--code", "123456"})
+	require.ErrorContains(t, err, "--code: invalid value ********")
+	require.NotContains(t, err.Error(), "123456")
+}
+
+func TestSecretFile(t *testing.T) {
+	t.Parallel()
+
+	secret := writeFile(t, "secret", "from-file\n")
+
+	for _, tc := range []struct {
+		name   string
+		args   []string
+		env    map[string]string
+		config string
+	}{
+		{name: "argv", args: []string{"--db-password-file", secret}},
+		{name: "argv equals", args: []string{"--db-password-file=" + secret}},
+		{name: "env", env: map[string]string{"DB_PASSWORD_FILE": secret}},
+		{name: "config", config: "db-password-file = " + secret + "\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			app := cligo.NewApp(cligo.WithLookupEnv(func(name string) (string, bool) {
+				value, ok := tc.env[name]
+				return value, ok
+			}))
+
+			if tc.config != "" {
+				require.NoError(t, app.LoadConfig(writeFile(t, "config", tc.config)))
+			}
+
+			var password string
+			app.AddOption("--db-password", &password, "database password", cligo.Env("DB_PASSWORD"), cligo.Secret())
+
+			require.NoError(t, app.ParseArgsStrict(tc.args))
+			require.Equal(t, "from-file", password)
+		})
+	}
+}
+
+func TestSecretFileNotSecret(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp()
+
+	var password string
+	app.AddOption("--db-password", &password, "database password")
+
+	err := app.ParseArgsStrict([]string{"--db-password-file", "secret"})
+	require.Error(t, err)
+}
+
+func TestResponseFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "nested.txt")
+	require.NoError(t, os.WriteFile(nested, []byte("--verbose\n"), 0o600))
+
+	args := filepath.Join(dir, "args.txt")
+	require.NoError(t, os.WriteFile(args, []byte(`# build arguments
+--file "my file.txt"   # a comment
+--name='it''s' @`+nested+`
+`), 0o600))
+
+	app := cligo.NewApp(cligo.WithResponseFiles())
+
+	var filename string
+	var name string
+	var verbose bool
+	oFile := app.AddOption("-f,--file", &filename, "filename")
+	app.AddOption("--name", &name, "name")
+	app.AddFlag("-v,--verbose", &verbose, "increase verbosity")
+
+	rest, err := app.ParseArgs([]string{"@" + args, "--", "@literal"})
+	require.NoError(t, err)
+	require.Equal(t, "my file.txt", filename)
+	require.Equal(t, "its", name)
+	require.True(t, verbose)
+	require.Equal(t, []string{"@literal"}, rest)
+	require.Equal(t, cligo.Source{Kind: cligo.SourceArgv, Index: 0, File: args}, oFile.Source())
+}
+
+func TestResponseFilesEndOfOptions(t *testing.T) {
+	t.Parallel()
+
+	a := writeFile(t, "a.rsp", "-v --\n")
+	b := writeFile(t, "b.rsp", "-v\n")
+
+	app := cligo.NewApp(cligo.WithResponseFiles())
+
+	var verbose int
+	app.AddFlag("-v,--verbose", &verbose, "increase verbosity")
+
+	rest, err := app.ParseArgs([]string{"@" + a, "@" + b})
+	require.NoError(t, err)
+	require.Equal(t, 1, verbose)
+	require.Equal(t, []string{"@" + b}, rest)
+}
+
+func TestResponseFilesErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cycle := filepath.Join(dir, "cycle.txt")
+	require.NoError(t, os.WriteFile(cycle, []byte("-v @"+cycle+"\n"), 0o600))
+
+	bad := filepath.Join(dir, "bad.txt")
+	require.NoError(t, os.WriteFile(bad, []byte("--bogus\n"), 0o600))
+
+	app := cligo.NewApp(cligo.WithResponseFiles())
+
+	var verbose bool
+	app.AddFlag("-v,--verbose", &verbose, "increase verbosity")
+
+	err := app.ParseArgsStrict([]string{"@" + cycle})
+	require.ErrorContains(t, err, "includes itself")
+
+	err = app.ParseArgsStrict([]string{"@" + bad})
+	require.ErrorContains(t, err, "response file "+bad+": the following argument was not expected: --bogus")
+
+	err = app.ParseArgsStrict([]string{"@" + filepath.Join(dir, "missing.txt")})
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	app = cligo.NewApp()
+	app.AddFlag("-v,--verbose", &verbose, "increase verbosity")
+	rest, err := app.ParseArgs([]string{"@" + bad})
+	require.NoError(t, err)
+	require.Equal(t, []string{"@" + bad}, rest)
+}
+
+func TestParseString(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp()
+
+	var filename string
+	var message string
+	var verbose bool
+	app.AddOption("-f,--file", &filename, "filename")
+	app.AddOption("-m,--message", &message, "message")
+	app.AddFlag("-v,--verbose", &verbose, "increase verbosity")
+
+	rest, err := app.ParseString(`-v --file 'my file.txt' -m "say \"hi\" to \$USER"  rest\ 1 '' # comment`)
+	require.NoError(t, err)
+	require.True(t, verbose)
+	require.Equal(t, "my file.txt", filename)
+	require.Equal(t, `say "hi" to $USER`, message)
+	require.Equal(t, []string{"rest 1", ""}, rest)
+
+	_, err = app.ParseString(`--file "unterminated`)
+	require.Error(t, err)
+	require.Equal(t, cligo.ExitUsage, cligo.NewApp(cligo.WithStderr(&bytes.Buffer{})).Exit(err))
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	t.Parallel()
+
+	for input, expected := range map[string][]string{
+		``:                      nil,
+		`a b  c`:                {"a", "b", "c"},
+		`a\ b "c d" 'e f'`:      {"a b", "c d", "e f"},
+		`"a\nb" 'a\nb' a\nb`:    {`a\nb`, `a\nb`, "anb"},
+		"a \\\n b":              {"a", "b"},
+		`a#b # c`:               {"a#b"},
+		`pre"mid"'post'`:        {"premidpost"},
+		`"$HOME" '$HOME' $HOME`: {"$HOME", "$HOME", "$HOME"},
+	} {
+		words, err := cligo.SplitCommandLine(input)
+		require.NoError(t, err, input)
+		require.Equal(t, expected, words, input)
+	}
+
+	_, err := cligo.SplitCommandLine(`'unterminated`)
+	require.Error(t, err)
+}
+
+func TestArgsEnv(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{"MYAPP_OPTS": `-v --name 'from env' --port=8080`}
+	lookupEnv := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	app := cligo.NewApp(cligo.WithArgsEnv("MYAPP_OPTS"), cligo.WithLookupEnv(lookupEnv))
+
+	var name string
+	var port int
+	var verbose bool
+	app.AddOption("--name", &name, "name")
+	nameOpt, _ := app.Lookup("--name")
+	app.AddOption("--port", &port, "port")
+	portOpt, _ := app.Lookup("--port")
+	app.AddFlag("-v", &verbose, "verbose")
+
+	err := app.ParseArgsStrict([]string{"--port", "9000"})
+	require.NoError(t, err)
+	require.True(t, verbose)
+	require.Equal(t, "from env", name)
+	require.Equal(t, 9000, port)
+	require.Equal(t, "argv[1] from $MYAPP_OPTS", nameOpt.Source().String())
+	require.Equal(t, "argv[4]", portOpt.Source().String())
+
+	env["MYAPP_OPTS"] = "--port nope"
+	_, err = app.ParseArgs(nil)
+	require.ErrorContains(t, err, "environment variable MYAPP_OPTS")
+
+	env["MYAPP_OPTS"] = `--name "unterminated`
+	_, err = app.ParseArgs(nil)
+	require.ErrorContains(t, err, "environment variable MYAPP_OPTS")
+}
+
+func TestPermutation(t *testing.T) {
+	t.Parallel()
+
+	newApp := func(opts ...cligo.AppOption) (*cligo.App, *string, *bool) {
+		opts = append(opts, cligo.WithLookupEnv(func(string) (string, bool) { return "", false }))
+		app := cligo.NewApp(opts...)
+
+		var input string
+		var verbose bool
+		app.AddOption("input", &input, "input file")
+		app.AddFlag("-v", &verbose, "verbose")
+		return app, &input, &verbose
+	}
+
+	app, input, verbose := newApp()
+	rest, err := app.ParseArgs([]string{"input.txt", "-v", "extra", "--", "-x"})
+	require.NoError(t, err)
+	require.Equal(t, "input.txt", *input)
+	require.True(t, *verbose)
+	require.Equal(t, []string{"extra", "-x"}, rest)
+
+	app, input, verbose = newApp(cligo.WithPosixlyCorrect())
+	rest, err = app.ParseArgs([]string{"input.txt", "-v"})
+	require.NoError(t, err)
+	require.Equal(t, "input.txt", *input)
+	require.False(t, *verbose)
+	require.Equal(t, []string{"-v"}, rest)
+
+	app = cligo.NewApp(cligo.WithLookupEnv(func(name string) (string, bool) {
+		return "", name == "POSIXLY_CORRECT"
+	}))
+	var verboseFlag bool
+	app.AddFlag("-v", &verboseFlag, "verbose")
+	rest, err = app.ParseArgs([]string{"cmd", "-v"})
+	require.NoError(t, err)
+	require.False(t, verboseFlag)
+	require.Equal(t, []string{"cmd", "-v"}, rest)
+}
+
+func TestNegativeNumbers(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp()
+
+	var offset int
+	var temperature float64
+	var verbose bool
+	app.AddOption("-n,--offset", &offset, "offset")
+	app.AddOption("temperature", &temperature, "temperature")
+	app.AddFlag("-v", &verbose, "verbose")
+
+	rest, err := app.ParseArgs([]string{"-n", "-5", "-1.5e1", "-v", "-0x10"})
+	require.NoError(t, err)
+	require.Equal(t, -5, offset)
+	require.Equal(t, -15.0, temperature)
+	require.True(t, verbose)
+	require.Equal(t, []string{"-0x10"}, rest)
+
+	app = cligo.NewApp()
+
+	var one bool
+	app.AddOption("temperature", &temperature, "temperature")
+	app.AddFlag("-1", &one, "one")
+
+	_, err = app.ParseArgs([]string{"-1"})
+	require.NoError(t, err)
+	require.True(t, one)
+
+	_, err = app.ParseArgs([]string{"-5"})
+	require.ErrorContains(t, err, "the following argument was not expected: -5")
+
+	// an option named "-" has an empty short name
+	app = cligo.NewApp()
+
+	var dash string
+	app.AddOption("temperature", &temperature, "temperature")
+	app.AddOption("-", &dash, "")
+
+	_, err = app.ParseArgs([]string{"-5"})
+	require.NoError(t, err)
+	require.Equal(t, -5.0, temperature)
+}
+
+func TestOptionalValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args  []string
+		color string
+		rest  []string
+	}{
+		{[]string{"--color"}, "auto", nil},
+		{[]string{"--color=never"}, "never", nil},
+		{[]string{"--color", "never"}, "auto", []string{"never"}},
+		{[]string{"-c"}, "auto", nil},
+		{[]string{"-calways"}, "always", nil},
+		{[]string{"-c", "never"}, "auto", []string{"never"}},
+		{[]string{}, "", nil},
+	}
+
+	for _, test := range tests {
+		app := cligo.NewApp()
+
+		var color string
+		app.AddOption("-c,--color", &color, "colorize output", cligo.OptionalValue("auto"), cligo.Metavar("WHEN"))
+
+		rest, err := app.ParseArgs(test.args)
+		require.NoError(t, err)
+		require.Equal(t, test.color, color, test.args)
+		require.Equal(t, test.rest, rest, test.args)
+	}
+
+	var out bytes.Buffer
+	app := cligo.NewApp(cligo.WithStdout(&out))
+
+	var color string
+	app.AddOption("-c,--color", &color, "colorize output", cligo.OptionalValue("auto"), cligo.Metavar("WHEN"))
+	app.Usage()
+	require.Contains(t, out.String(), "-c,--color[=WHEN]")
+
+	opt, _ := app.Lookup("--color")
+	implicit, ok := opt.ImplicitValue()
+	require.True(t, ok)
+	require.Equal(t, "auto", implicit)
+}
+
+func TestArity(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	app := cligo.NewApp(cligo.WithStdout(&out), cligo.WithLookupEnv(func(name string) (string, bool) {
+		return "1 2 3", name == "RANGE"
+	}))
+
+	var size [2]int
+	var points []float64
+	var tags []string
+	var rng []int
+	var verbose bool
+	app.AddOption("-s,--resize", &size, "new size", cligo.Arity(2))
+	app.AddOption("-p,--point", &points, "a point", cligo.Arity(2))
+	app.AddOption("-t,--tags", &tags, "tags", cligo.ArityRange(1, 3))
+	app.AddOption("--range", &rng, "range", cligo.ArityRange(2, 3), cligo.Env("RANGE"))
+	app.AddFlag("-v", &verbose, "verbose")
+
+	rest, err := app.ParseArgs([]string{"--resize", "800", "600", "-p", "1", "-2.5", "--point=3", "4", "-t", "a", "b", "-v", "c"})
+	require.NoError(t, err)
+	require.Equal(t, [2]int{800, 600}, size)
+	require.Equal(t, []float64{1, -2.5, 3, 4}, points)
+	require.Equal(t, []string{"a", "b"}, tags)
+	require.Equal(t, []int{1, 2, 3}, rng)
+	require.True(t, verbose)
+	require.Equal(t, []string{"c"}, rest)
+
+	opt, _ := app.Lookup("--point")
+	require.Equal(t, 2, opt.Count())
+	for _, p := range app.Provenance() {
+		if p.Option == opt {
+			require.Equal(t, "1 -2.5 3 4", p.Value)
+		}
+	}
+
+	min, max := opt.Arity()
+	require.Equal(t, 2, min)
+	require.Equal(t, 2, max)
+
+	_, err = app.ParseArgs([]string{"-s800"})
+	require.ErrorIs(t, err, cligo.ErrMissingParameter)
+	require.ErrorContains(t, err, "--resize expects 2 values, got 1")
+
+	app.Usage()
+	require.Contains(t, out.String(), "-s,--resize NUMBER NUMBER")
+	require.Contains(t, out.String(), "-t,--tags TEXT [TEXT...]")
+}
+
+func TestSliceValuesReplaced(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp()
+
+	tags := []string{"default"}
+	var ports []int
+	app.AddOption("--tag", &tags, "tags", cligo.CaptureDefault())
+	app.AddOption("--port", &ports, "ports", cligo.Arity(2), cligo.Default("1 2"))
+
+	_, err := app.ParseArgs([]string{"--tag", "x"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"x"}, tags)
+	require.Equal(t, []int{1, 2}, ports)
+
+	_, err = app.ParseArgs([]string{"--tag", "y", "--tag", "z"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"y", "z"}, tags)
+	require.Equal(t, []int{1, 2}, ports)
+
+	_, err = app.ParseArgs([]string{"--port", "3", "4"})
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 4}, ports)
+}
+
+func TestVariadicPositionals(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	newApp := func() (*cligo.App, *[]string, *string) {
+		out.Reset()
+		app := cligo.NewApp(cligo.WithStdout(&out), cligo.WithProgramName("prog"))
+
+		var sources []string
+		var dest string
+		var verbose bool
+		app.AddOption("SRC", &sources, "source files", cligo.Expected(1, -1))
+		app.AddOption("DST", &dest, "destination", cligo.Required())
+		app.AddFlag("-v", &verbose, "verbose")
+		return app, &sources, &dest
+	}
+
+	app, sources, dest := newApp()
+	rest, err := app.ParseArgs([]string{"a", "-v", "b", "c", "dir"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, *sources)
+	require.Equal(t, "dir", *dest)
+	require.Empty(t, rest)
+
+	app.Usage()
+	require.Contains(t, out.String(), "Usage: prog [OPTIONS] SRC... DST\n")
+
+	app, sources, dest = newApp()
+	_, err = app.ParseArgs([]string{"a", "dir"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, *sources)
+	require.Equal(t, "dir", *dest)
+
+	app, _, _ = newApp()
+	_, err = app.ParseArgs([]string{"a"})
+	require.ErrorContains(t, err, "DST is required")
+
+	app, _, _ = newApp()
+	_, err = app.ParseArgs(nil)
+	require.ErrorContains(t, err, "SRC is required")
+
+	app = cligo.NewApp()
+
+	var pair []int
+	var files []string
+	app.AddOption("PAIR", &pair, "a pair of numbers", cligo.Expected(2, 2))
+	app.AddOption("FILES", &files, "files")
+
+	rest, err = app.ParseArgs([]string{"1", "2", "x", "y"})
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2}, pair)
+	require.Equal(t, []string{"x", "y"}, files)
+	require.Empty(t, rest)
+
+	_, err = app.ParseArgs([]string{"1"})
+	require.ErrorContains(t, err, "PAIR expects 2 values, got 1")
+}
+
+func TestPassthrough(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	app := cligo.NewApp(cligo.WithStdout(&out), cligo.WithProgramName("tool"))
+
+	var action string
+	var verbose bool
+	var command []string
+	app.AddOption("ACTION", &action, "action")
+	app.AddFlag("-v", &verbose, "verbose")
+	app.AddPassthrough("COMMAND", &command)
+
+	rest, err := app.ParseArgs([]string{"run", "extra", "-v", "--", "cmd", "-v", "--", "args"})
+	require.NoError(t, err)
+	require.Equal(t, "run", action)
+	require.True(t, verbose)
+	require.Equal(t, []string{"cmd", "-v", "--", "args"}, command)
+	require.Equal(t, []string{"extra"}, rest)
+
+	require.NoError(t, app.ParseArgsStrict([]string{"run", "--", "cmd"}))
+	require.Equal(t, []string{"cmd"}, command)
+
+	require.NoError(t, app.ParseArgsStrict([]string{"run"}))
+	require.Nil(t, command)
+
+	app.Usage()
+	require.Contains(t, out.String(), "Usage: tool [OPTIONS] ACTION [-- COMMAND...]\n")
+}
+
+func TestPassthroughPosixlyCorrect(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp(cligo.WithPosixlyCorrect())
+
+	var action string
+	var command []string
+	app.AddOption("ACTION", &action, "action")
+	app.AddPassthrough("COMMAND", &command)
+
+	rest, err := app.ParseArgs([]string{"run", "-x", "--", "ls", "-l"})
+	require.NoError(t, err)
+	require.Equal(t, "run", action)
+	require.Equal(t, []string{"ls", "-l"}, command)
+	require.Equal(t, []string{"-x"}, rest)
+}
+
+func TestAllowUnknown(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp(cligo.WithAllowUnknown())
+
+	var name string
+	var verbose bool
+	var file string
+	app.AddOption("-n,--name", &name, "name")
+	app.AddFlag("-v", &verbose, "verbose")
+	app.AddOption("FILE", &file, "file")
+
+	rest, err := app.ParseArgs([]string{"--foo=bar", "-x", "--name", "me", "-vyz", "--baz", "-q", "input.txt"})
+	require.NoError(t, err)
+	require.Equal(t, "me", name)
+	require.True(t, verbose)
+	require.Equal(t, []string{"--foo=bar", "-x", "-yz", "--baz", "-q"}, app.Unknown())
+	require.Equal(t, "input.txt", file)
+	require.Empty(t, rest)
+
+	_, err = app.ParseArgs([]string{"input.txt"})
+	require.NoError(t, err)
+	require.Empty(t, app.Unknown())
+	require.Equal(t, "input.txt", file)
+
+	_, err = cligo.NewApp().ParseArgs([]string{"--foo"})
+	require.ErrorContains(t, err, "the following argument was not expected: --foo")
+}
+
+func TestUnknownValues(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp(cligo.WithUnknownValues())
+
+	var name string
+	var verbose bool
+	app.AddOption("-n,--name", &name, "name")
+	app.AddFlag("-v", &verbose, "verbose")
+
+	rest, err := app.ParseArgs([]string{"--foo=bar", "-x", "1", "--name", "me", "-vyz", "--baz", "-q", "input.txt"})
+	require.NoError(t, err)
+	require.Equal(t, "me", name)
+	require.True(t, verbose)
+	require.Equal(t, []string{"--foo=bar", "-x", "1", "-yz", "--baz", "-q", "input.txt"}, app.Unknown())
+	require.Empty(t, rest)
+}
+
+func TestSingleDashLong(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp(cligo.WithSingleDashLong())
+
+	var name string
+	var verbose bool
+	var all, brief bool
+	var level int
+	app.AddOption("-n,--name", &name, "name")
+	app.AddFlag("-v,--verbose", &verbose, "verbose")
+	app.AddFlag("-a", &all, "all")
+	app.AddFlag("-b", &brief, "brief")
+	app.AddOption("-l,--level", &level, "level")
+	app.AddFlag("--ab", &verbose, "conflicts with -a -b")
+
+	rest, err := app.ParseArgs([]string{"-name", "me", "-verbose", "-level=3", "-v", "-ba", "input"})
+	require.NoError(t, err)
+	require.Equal(t, "me", name)
+	require.True(t, verbose)
+	require.True(t, all)
+	require.True(t, brief)
+	require.Equal(t, 3, level)
+	require.Equal(t, []string{"input"}, rest)
+
+	_, err = app.ParseArgs([]string{"-ab"})
+	require.ErrorContains(t, err, "the argument -ab is ambiguous")
+
+	_, err = cligo.NewApp().ParseArgs([]string{"-name"})
+	require.ErrorContains(t, err, "the following argument was not expected: -name")
+}
+
+func TestSingleDashLongBuiltins(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+	app := cligo.NewApp(
+		cligo.WithSingleDashLong(),
+		cligo.WithVersion("1.2.3"),
+		cligo.WithStdout(&stdout),
+		cligo.WithErrorOnHelp(),
+		cligo.WithProgramName("my_app"),
+	)
+
+	var verbose bool
+	app.AddFlag("-v,--verbose", &verbose, "verbose")
+
+	_, err := app.ParseArgs([]string{"-help"})
+	require.ErrorIs(t, err, cligo.ErrHelpRequested)
+	require.Contains(t, stdout.String(), "Usage: my_app")
+
+	stdout.Reset()
+	_, err = app.ParseArgs([]string{"-version"})
+	require.ErrorIs(t, err, cligo.ErrVersionRequested)
+	require.Equal(t, "my_app 1.2.3\n", stdout.String())
+
+	_, err = cligo.NewApp(cligo.WithSingleDashLong()).ParseArgs([]string{"-version"})
+	require.Error(t, err)
+	require.NotErrorIs(t, err, cligo.ErrVersionRequested)
+}
+
+func TestFromFlagSet(t *testing.T) {
+	t.Parallel()
+
+	fs := flag.NewFlagSet("legacy", flag.ContinueOnError)
+	verbose := fs.Bool("verbose", false, "enable verbose output")
+	name := fs.String("name", "world", "the `person` to greet")
+	count := fs.Int("n", 1, "number of greetings")
+	var tags []string
+	fs.Func("tag", "add a tag", func(s string) error {
+		tags = append(tags, s)
+		return nil
+	})
+
+	var out bytes.Buffer
+	app := cligo.FromFlagSet(fs, map[string]string{"verbose": "v"}, cligo.WithStdout(&out))
+
+	rest, err := app.ParseArgs([]string{"-v", "-name", "gopher", "-n", "3", "--tag=a", "-tag", "b", "file"})
+	require.NoError(t, err)
+	require.True(t, *verbose)
+	require.Equal(t, "gopher", *name)
+	require.Equal(t, 3, *count)
+	require.Equal(t, []string{"a", "b"}, tags)
+	require.Equal(t, []string{"file"}, rest)
+
+	_, err = app.ParseArgs([]string{"-n", "x"})
+	require.Error(t, err)
+
+	opt, ok := app.Lookup("--name")
+	require.True(t, ok)
+	require.Equal(t, "world", opt.DefaultString())
+	require.Equal(t, "PERSON", opt.Metavar())
+
+	app.Usage()
+	require.Contains(t, out.String(), "Usage: legacy [OPTIONS]")
+	require.Contains(t, out.String(), "-v,--verbose")
+	require.Contains(t, out.String(), "-n,--n INT [1]")
+
+	require.Panics(t, func() {
+		cligo.FromFlagSet(fs, map[string]string{"missing": "m"})
+	})
+}
+
+func TestFromFlagSetSingleLetter(t *testing.T) {
+	t.Parallel()
+
+	fs := flag.NewFlagSet("legacy", flag.ContinueOnError)
+	count := fs.Int("n", 1, "number of greetings")
+	extra := fs.Bool("x", true, "extra output")
+
+	app := cligo.FromFlagSet(fs, nil)
+
+	_, err := app.ParseArgs([]string{"-n=5", "-x=false"})
+	require.NoError(t, err)
+	require.Equal(t, 5, *count)
+	require.False(t, *extra)
+
+	_, err = app.ParseArgs([]string{"--n", "6", "-x"})
+	require.NoError(t, err)
+	require.Equal(t, 6, *count)
+	require.True(t, *extra)
+
+	_, err = app.ParseArgs([]string{"-n7", "--x=false"})
+	require.NoError(t, err)
+	require.Equal(t, 7, *count)
+	require.False(t, *extra)
+}
+
+func TestSingleDashShortEquals(t *testing.T) {
+	t.Parallel()
+
+	app := cligo.NewApp(cligo.WithSingleDashLong())
+
+	var level int
+	var verbose bool
+	app.AddOption("-l", &level, "level")
+	app.AddFlag("-v", &verbose, "verbose")
+
+	_, err := app.ParseArgs([]string{"-l=3", "-v=true"})
+	require.NoError(t, err)
+	require.Equal(t, 3, level)
+	require.True(t, verbose)
+
+	_, err = app.ParseArgs([]string{"-v=false"})
+	require.NoError(t, err)
+	require.False(t, verbose)
+
+	// without WithSingleDashLong, the "=" is part of the value
+	var name string
+	other := cligo.NewApp()
+	other.AddOption("-n", &name, "name")
+	_, err = other.ParseArgs([]string{"-n=me"})
+	require.NoError(t, err)
+	require.Equal(t, "=me", name)
+}
+
+func TestFromGetopt(t *testing.T) {
+	t.Parallel()
+
+	app, err := cligo.FromGetopt("hab:c::", []string{"verbose", "file:", "color::"})
+	require.NoError(t, err)
+
+	rest, err := app.ParseArgs([]string{"-aa", "-b", "x", "-cy", "--verbose", "--file=f", "--color", "input"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"input"}, rest)
+
+	value := func(name string) any {
+		opt, ok := app.Lookup(name)
+		require.True(t, ok, name)
+		return opt.Value()
+	}
+
+	require.Equal(t, 2, *value("-a").(*int))
+	require.Equal(t, "x", *value("-b").(*string))
+	require.Equal(t, "y", *value("-c").(*string))
+	require.Equal(t, 1, *value("--verbose").(*int))
+	require.Equal(t, "f", *value("--file").(*string))
+	require.Equal(t, "", *value("--color").(*string))
+
+	opt, _ := app.Lookup("--color")
+	require.True(t, opt.Exists())
+
+	_, err = cligo.FromGetopt("a!", nil)
+	require.ErrorContains(t, err, "invalid option character")
+
+	_, err = cligo.FromGetopt("", []string{"file:", "file"})
+	require.ErrorIs(t, err, cligo.ErrDuplicateOption)
+}
+
+func TestFromUsage(t *testing.T) {
+	t.Parallel()
+
+	usage := `Copy files.
+
+Usage:
+  cp [options] <src>... <dst>
+  cp (-r | -l) <src> <dst>
+
+Options:
+  -h, --help           Show this screen.
+  -v, --verbose        Explain what is being done.
+  -b, --backup=<mode>  Make a backup of each file [default: simple].
+  -r                   Copy directories recursively.
+  -l                   Hard link files instead of copying.
+`
+
+	var out bytes.Buffer
+	app, err := cligo.FromUsage(usage, cligo.WithStdout(&out))
+	require.NoError(t, err)
+
+	rest, err := app.ParseArgs([]string{"-vv", "a", "b", "dir"})
+	require.NoError(t, err)
+	require.Empty(t, rest)
+
+	value := func(name string) any {
+		opt, ok := app.Lookup(name)
+		require.True(t, ok, name)
+		return opt.Value()
+	}
+
+	require.Equal(t, 2, *value("--verbose").(*int))
+	require.Equal(t, "simple", *value("--backup").(*string))
+	require.Equal(t, []string{"a", "b"}, *value("<src>").(*[]string))
+	require.Equal(t, "dir", *value("<dst>").(*string))
+
+	_, err = app.ParseArgs([]string{"-r", "-l", "a", "dir"})
+	require.ErrorContains(t, err, "excludes")
+
+	app.Usage()
+	require.Contains(t, out.String(), "Usage: cp [OPTIONS] <src>... <dst>")
+	require.Contains(t, out.String(), "Copy files.")
+	require.Contains(t, out.String(), "-b,--backup <mode> [simple]")
+
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--name", "x"}, "FILE is required"},
+		{[]string{"file"}, "name is required"},
+		{[]string{"-q", "--name", "x", "file"}, ""},
+	} {
+		app, err = cligo.FromUsage("Usage: prog [-q] --name=NAME FILE")
+		require.NoError(t, err)
+
+		_, err = app.ParseArgs(test.args)
+		if test.err == "" {
+			require.NoError(t, err)
+		} else {
+			require.ErrorContains(t, err, test.err)
+		}
+	}
+
+	_, err = cligo.FromUsage("Usage: git add <file>")
+	require.ErrorContains(t, err, "commands such as add are not supported")
+
+	_, err = cligo.FromUsage("Usage: prog (-a | -b")
+	require.ErrorContains(t, err, "unmatched (")
+
+	_, err = cligo.FromUsage("prog [options]")
+	require.Error(t, err)
+}
@@ -0,0 +1,313 @@
+package cligo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+type configEntry struct {
+	section string
+	key     string
+	value   string
+	file    string
+	line    int
+}
+
+// WithConfigDiscovery causes the application to search for configuration files named after
+// name in the standard locations each time the command line is parsed. See ConfigSearchPaths
+// for the locations which are searched. Files which do not exist are silently ignored.
+func WithConfigDiscovery(name string) AppOption {
+	return func(app *App) {
+		app.configName = name
+	}
+}
+
+// ConfigSearchPaths returns the paths searched for configuration files when WithConfigDiscovery
+// is used, from lowest to highest priority:
+//
+//   - /etc/<name>/config
+//   - ~/.config/<name>/config
+//   - $XDG_CONFIG_HOME/<name>/config
+//   - .<name>.conf in the current directory
+//
+// All of the files which exist are loaded, in this order, so values in more specific files
+// override those in more general ones.
+func (a App) ConfigSearchPaths() []string {
+	if a.configName == "" {
+		return nil
+	}
+
+	paths := []string{filepath.Join("/etc", a.configName, "config")}
+
+	if home, ok := a.lookupEnv("HOME"); ok && home != "" {
+		paths = append(paths, filepath.Join(home, ".config", a.configName, "config"))
+	}
+
+	if xdg, ok := a.lookupEnv("XDG_CONFIG_HOME"); ok && xdg != "" {
+		path := filepath.Join(xdg, a.configName, "config")
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+
+	return append(paths, "."+a.configName+".conf")
+}
+
+// ConfigFiles returns the paths of the configuration files which have been loaded, both by
+// LoadConfig and during the most recent parse, in the order they were loaded.
+func (a App) ConfigFiles() []string {
+	return append(slices.Clip(a.configFiles), a.parsedConfigFiles...)
+}
+
+// LoadConfig reads option values from the configuration file at path. Each line of the file
+// is of the form:
+//
+//	name = value
+//
+// where name is the long name of an option (without the leading dashes) or the name of a
+// positional. Blank lines and lines starting with # or ; are ignored, and values may be
+// surrounded by single or double quotes.
+//
+// Values may be placed in sections, introduced by a line such as:
+//
+//	[profile NAME]
+//
+// Values in the section for the profile selected by an option added with AddProfileOption are
+// layered over the values which appear before any section (or in a [default] section). Other
+// sections are ignored.
+//
+// The values are assigned when the command line is parsed, according to the application's
+// precedence, so by default they are overridden by environment variables and the command line.
+// Loading multiple files is allowed, in which case values from later files take priority.
+func (a *App) LoadConfig(path string) error {
+	entries, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+
+	a.config = append(a.config, entries...)
+	a.configFiles = append(a.configFiles, path)
+	return nil
+}
+
+// AddConfigOption adds an option whose value is the path of a configuration file, and returns a
+// pointer to an Option representing it. The option is resolved before all other options (see
+// PreParse), and the file it names is loaded as if by LoadConfig, so that values in the file
+// are available to the rest of the options.
+//
+// If the option's value comes from a Default modifier and the file does not exist, it is
+// silently ignored. Otherwise, failing to load the file is an error.
+//
+// ptr may be nil, in which case the path is stored internally.
+func (a *App) AddConfigOption(name string, ptr *string, help string, modifiers ...Modifier) *Option {
+	if ptr == nil {
+		ptr = new(string)
+	}
+
+	opt := a.AddOption(name, ptr, help, modifiers...)
+	opt.preParse = true
+	opt.isConfig = true
+	return opt
+}
+
+// AddProfileOption adds an option whose value selects a profile in the application's
+// configuration files, and returns a pointer to an Option representing it. Like options added
+// with AddConfigOption, it is resolved before all other options.
+//
+// Selecting a profile which does not appear in any configuration file is an error.
+//
+// ptr may be nil, in which case the profile name is stored internally.
+func (a *App) AddProfileOption(name string, ptr *string, help string, modifiers ...Modifier) *Option {
+	if ptr == nil {
+		ptr = new(string)
+	}
+
+	opt := a.AddOption(name, ptr, help, modifiers...)
+	opt.preParse = true
+	opt.isProfile = true
+	return opt
+}
+
+// loadConfigs loads the configuration files found by WithConfigDiscovery, followed by those
+// named by options added with AddConfigOption
+func (p *parser) loadConfigs() error {
+	for _, path := range p.app.ConfigSearchPaths() {
+		if err := p.loadConfig(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	for _, opt := range p.app.options {
+		if !opt.isConfig {
+			continue
+		}
+
+		path := getValue(opt.ptr)
+		if path == "" {
+			continue
+		}
+
+		if err := p.loadConfig(path); err != nil {
+			if errors.Is(err, os.ErrNotExist) && opt.source.Kind == SourceDefault {
+				continue
+			}
+			return err
+		}
+	}
+
+	p.app.parsedConfigFiles = p.configFiles
+	p.configLoaded = true
+	return nil
+}
+
+func (p *parser) loadConfig(path string) error {
+	entries, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+
+	p.config = append(p.config, entries...)
+	p.configFiles = append(p.configFiles, path)
+	return nil
+}
+
+// profile returns the name of the profile selected by options added with AddProfileOption
+func (p *parser) profile() string {
+	for _, opt := range p.app.options {
+		if opt.isProfile {
+			if name := getValue(opt.ptr); name != "" {
+				return name
+			}
+		}
+	}
+
+	return ""
+}
+
+// configEntries returns the configuration values in the order they should be assigned, so that
+// values for the selected profile take priority over values in the default section
+func (p *parser) configEntries() ([]configEntry, error) {
+	all := append(slices.Clip(p.app.config), p.config...)
+
+	entries := filterFunc(all, func(entry configEntry) bool {
+		return entry.section == ""
+	})
+
+	profile := p.profile()
+	if profile == "" {
+		return entries, nil
+	}
+
+	section := "profile " + profile
+	profileEntries := filterFunc(all, func(entry configEntry) bool {
+		return entry.section == section
+	})
+
+	// the files which may contain the profile are only known once they have been loaded
+	if len(profileEntries) == 0 && p.configLoaded {
+		return nil, fmt.Errorf("profile '%s' was not found in any configuration file", profile)
+	}
+
+	return append(entries, profileEntries...), nil
+}
+
+func readConfig(path string) ([]configEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseConfig(f, path)
+}
+
+func parseConfig(r io.Reader, file string) ([]configEntry, error) {
+	var entries []configEntry
+	section := ""
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") {
+				return nil, fmt.Errorf("%s:%d: expected '[section]'", file, line)
+			}
+
+			section = strings.Join(strings.Fields(text[1:len(text)-1]), " ")
+			if section == "default" {
+				section = ""
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(text, "=")
+		if !found {
+			return nil, fmt.Errorf("%s:%d: expected 'name = value'", file, line)
+		}
+
+		entries = append(entries, configEntry{
+			section: section,
+			key:     strings.TrimSpace(key),
+			value:   unquote(strings.TrimSpace(value)),
+			file:    file,
+			line:    line,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// unquote removes a matching pair of single or double quotes surrounding value
+func unquote(value string) string {
+	if len(value) >= 2 {
+		if (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
+func (a App) configAssignments(entries []configEntry) ([]assignment, error) {
+	var layer []assignment
+	for _, entry := range entries {
+		opt, isNegated, exists := a.findLongOption(entry.key)
+		if !exists {
+			opt, exists = a.findPositional(entry.key)
+		}
+
+		fromFile := false
+		if !exists {
+			opt, fromFile = a.findSecretFileOption(entry.key)
+			exists = fromFile
+		}
+
+		if !exists {
+			return nil, fmt.Errorf("%s:%d: unknown option '%s'", entry.file, entry.line, entry.key)
+		}
+
+		layer = append(layer, assignment{
+			opt:       opt,
+			value:     entry.value,
+			isNegated: isNegated,
+			source:    Source{Kind: SourceConfig, Name: entry.key, File: entry.file, Line: entry.line},
+			fromFile:  fromFile,
+		})
+	}
+
+	return layer, nil
+}
@@ -0,0 +1,479 @@
+package cligo
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var defaultPattern = regexp.MustCompile(`(?i)\[default:\s*([^\]]*)\]`)
+
+// optionSpec describes an option from the options section of a usage message
+type optionSpec struct {
+	names      []string
+	metavar    string
+	help       string
+	defaultVal string
+	hasDefault bool
+	repeated   bool
+	opt        *Option
+}
+
+type usageNodeKind int
+
+const (
+	usageOption usageNodeKind = iota
+	usagePositional
+	usageGroup
+)
+
+// usageNode is an element of a usage pattern: an option, a positional argument, or a group
+// of alternatives in parentheses or brackets
+type usageNode struct {
+	kind         usageNodeKind
+	name         string
+	repeated     bool
+	optional     bool
+	alternatives [][]*usageNode
+
+	// the description of an option
+	spec *optionSpec
+
+	// the option added for a positional argument
+	opt *Option
+}
+
+// FromUsage creates an application from a usage message in the style of docopt, to ease the
+// porting of scripts which use it. For example:
+//
+//	Copy files.
+//
+//	Usage:
+//	  cp [options] <src>... <dst>
+//	  cp (-r | -l) <src> <dst>
+//
+//	Options:
+//	  -v, --verbose        Explain what is being done.
+//	  -b, --backup=<mode>  Make a backup of each file [default: simple].
+//	  -r                   Copy directories recursively.
+//	  -l                   Hard link files instead of copying.
+//
+// Text before the usage section becomes the description. The usage section consists of the
+// lines following "Usage:" up to the first blank line, each starting with the program name.
+// Positional arguments are written as <name> or NAME, followed by "..." if they take several
+// arguments. Options are described by the lines starting with "-" after the usage section,
+// where an option followed by a placeholder takes a value, and "[default: value]" sets its
+// default. Options which are only mentioned in the usage patterns are also added. Elements in
+// brackets are optional, and the alternatives of a group such as (-r | -l) exclude each other.
+// If there is only one usage pattern, the elements outside brackets are required. Commands,
+// as in "git add", are not supported.
+//
+// The options are not bound to variables. Their values can be retrieved after parsing with
+// Lookup and Value, which is a *[]string for options and positional arguments followed by "...",
+// a *string for other options and positional arguments, and a *int counting the occurrences for
+// flags. Since cligo provides -h and --help itself, they are ignored.
+func FromUsage(usage string, options ...AppOption) (*App, error) {
+	lines := strings.Split(usage, "\n")
+
+	start := -1
+	for i, line := range lines {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(line)), "usage:") {
+			start = i
+			break
+		}
+	}
+
+	if start == -1 {
+		return nil, errors.New("the usage message has no usage section")
+	}
+
+	var patterns []string
+	if first := strings.TrimSpace(strings.TrimSpace(lines[start])[len("usage:"):]); first != "" {
+		patterns = append(patterns, first)
+	}
+
+	end := start + 1
+	for ; end < len(lines) && strings.TrimSpace(lines[end]) != ""; end++ {
+		patterns = append(patterns, strings.TrimSpace(lines[end]))
+	}
+
+	if len(patterns) == 0 {
+		return nil, errors.New("the usage section has no patterns")
+	}
+
+	var specs []*optionSpec
+	for _, line := range lines[end:] {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "-") {
+			specs = append(specs, parseOptionSpec(line))
+		}
+	}
+
+	p := &usageParser{specs: specs}
+
+	var programName string
+	var trees [][]*usageNode
+	for _, pattern := range patterns {
+		tokens := tokenizeUsage(pattern)
+		programName = tokens[0]
+
+		tree, err := p.parsePattern(tokens[1:])
+		if err != nil {
+			return nil, fmt.Errorf("usage pattern %q: %w", pattern, err)
+		}
+		trees = append(trees, tree)
+	}
+
+	if description := strings.TrimSpace(strings.Join(lines[:start], "\n")); description != "" {
+		options = append([]AppOption{WithDescription(description)}, options...)
+	}
+	options = append([]AppOption{WithProgramName(programName)}, options...)
+
+	app := NewApp(options...)
+	p.addOptions(app)
+
+	required := len(trees) == 1
+	excluded := make(map[[2]*Option]bool)
+	for _, tree := range trees {
+		p.applyRules(tree, required, excluded)
+	}
+
+	return app, nil
+}
+
+// parseOptionSpec parses a line of the options section such as "-o FILE, --output=FILE  help"
+func parseOptionSpec(line string) *optionSpec {
+	names, help, _ := strings.Cut(line, "  ")
+	if before, after, found := strings.Cut(names, "\t"); found {
+		names, help = before, after+help
+	}
+
+	spec := &optionSpec{help: strings.TrimSpace(help)}
+	for _, field := range strings.Fields(strings.NewReplacer(",", " ", "=", " ").Replace(names)) {
+		if strings.HasPrefix(field, "-") {
+			if field != "-" && field != "--" {
+				spec.names = append(spec.names, field)
+			}
+		} else {
+			spec.metavar = field
+		}
+	}
+
+	if match := defaultPattern.FindStringSubmatch(spec.help); match != nil {
+		spec.defaultVal = strings.TrimSpace(match[1])
+		spec.hasDefault = true
+	}
+
+	return spec
+}
+
+// tokenizeUsage splits a usage pattern into words, brackets, "|" and "..."
+func tokenizeUsage(pattern string) []string {
+	replacer := strings.NewReplacer(
+		"(", " ( ",
+		")", " ) ",
+		"[", " [ ",
+		"]", " ] ",
+		"|", " | ",
+		"...", " ... ",
+	)
+
+	return strings.Fields(replacer.Replace(pattern))
+}
+
+func isUsagePositional(word string) bool {
+	if strings.HasPrefix(word, "<") && strings.HasSuffix(word, ">") {
+		return true
+	}
+
+	return word == strings.ToUpper(word) && strings.ContainsAny(word, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+}
+
+type usageParser struct {
+	specs       []*optionSpec
+	positionals []*usageNode
+	tokens      []string
+	pos         int
+}
+
+func (p *usageParser) findSpec(name string) *optionSpec {
+	for _, spec := range p.specs {
+		for _, n := range spec.names {
+			if n == name {
+				return spec
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *usageParser) parsePattern(tokens []string) ([]*usageNode, error) {
+	p.tokens = tokens
+	p.pos = 0
+
+	alternatives, err := p.parseAlternatives()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %s", p.tokens[p.pos])
+	}
+
+	if len(alternatives) > 1 {
+		return []*usageNode{{kind: usageGroup, alternatives: alternatives}}, nil
+	}
+
+	return alternatives[0], nil
+}
+
+// parseAlternatives parses a sequence of elements separated by "|", up to a closing bracket
+func (p *usageParser) parseAlternatives() ([][]*usageNode, error) {
+	alternatives := [][]*usageNode{nil}
+
+	for p.pos < len(p.tokens) {
+		token := p.tokens[p.pos]
+
+		switch token {
+		case ")", "]":
+			return alternatives, nil
+		case "|":
+			p.pos++
+			alternatives = append(alternatives, nil)
+			continue
+		}
+
+		nodes, err := p.parseElement()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.pos < len(p.tokens) && p.tokens[p.pos] == "..." {
+			p.pos++
+			for _, node := range nodes {
+				node.setRepeated()
+			}
+		}
+
+		alternatives[len(alternatives)-1] = append(alternatives[len(alternatives)-1], nodes...)
+	}
+
+	return alternatives, nil
+}
+
+// parseElement parses a single element of a usage pattern, which may be several short options
+// written together
+func (p *usageParser) parseElement() ([]*usageNode, error) {
+	token := p.tokens[p.pos]
+	p.pos++
+
+	switch {
+	case token == "(" || token == "[":
+		closing := map[string]string{"(": ")", "[": "]"}[token]
+
+		// [options] stands for all of the options in the options section
+		if token == "[" && p.pos+1 < len(p.tokens) && p.tokens[p.pos] == "options" && p.tokens[p.pos+1] == "]" {
+			p.pos += 2
+			return nil, nil
+		}
+
+		alternatives, err := p.parseAlternatives()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.pos >= len(p.tokens) || p.tokens[p.pos] != closing {
+			return nil, fmt.Errorf("unmatched %s", token)
+		}
+		p.pos++
+
+		return []*usageNode{{kind: usageGroup, optional: token == "[", alternatives: alternatives}}, nil
+	case token == "..." || token == "|":
+		return nil, fmt.Errorf("unexpected %s", token)
+	case token == "--":
+		// cligo always accepts -- to end the options
+		return nil, nil
+	case strings.HasPrefix(token, "--") && len(token) > 2:
+		name, metavar, hasValue := strings.Cut(token, "=")
+		spec := p.addSpec(name, metavar, hasValue)
+		p.skipValue(spec, hasValue)
+		return []*usageNode{{kind: usageOption, name: name, spec: spec}}, nil
+	case strings.HasPrefix(token, "-") && len(token) > 1:
+		var nodes []*usageNode
+		for _, ch := range token[1:] {
+			name := "-" + string(ch)
+			spec := p.addSpec(name, "", false)
+			nodes = append(nodes, &usageNode{kind: usageOption, name: name, spec: spec})
+		}
+		p.skipValue(nodes[len(nodes)-1].spec, false)
+		return nodes, nil
+	case isUsagePositional(token):
+		// each positional argument is represented by a single node wherever it appears
+		for _, node := range p.positionals {
+			if node.name == token {
+				return []*usageNode{node}, nil
+			}
+		}
+
+		node := &usageNode{kind: usagePositional, name: token}
+		p.positionals = append(p.positionals, node)
+		return []*usageNode{node}, nil
+	default:
+		return nil, fmt.Errorf("commands such as %s are not supported", token)
+	}
+}
+
+// addSpec returns the spec for the option name, adding one if it was not described in the
+// options section
+func (p *usageParser) addSpec(name string, metavar string, hasValue bool) *optionSpec {
+	spec := p.findSpec(name)
+	if spec == nil {
+		spec = &optionSpec{names: []string{name}}
+		p.specs = append(p.specs, spec)
+	}
+
+	if hasValue && spec.metavar == "" {
+		spec.metavar = metavar
+	}
+
+	return spec
+}
+
+// skipValue skips the placeholder for the value of an option written as in -o FILE
+func (p *usageParser) skipValue(spec *optionSpec, hasValue bool) {
+	if !hasValue && spec.metavar != "" && p.pos < len(p.tokens) && isUsagePositional(p.tokens[p.pos]) {
+		p.pos++
+	}
+}
+
+func (node *usageNode) setRepeated() {
+	node.repeated = true
+	if node.spec != nil {
+		node.spec.repeated = true
+	}
+
+	for _, alternative := range node.alternatives {
+		for _, child := range alternative {
+			child.setRepeated()
+		}
+	}
+}
+
+// addOptions adds the options and positional arguments found in the usage message to app
+func (p *usageParser) addOptions(app *App) {
+	for _, spec := range p.specs {
+		names := strings.Join(spec.names, ",")
+		if len(spec.names) == 0 || spec.names[0] == "-h" || spec.names[0] == "--help" {
+			continue
+		}
+
+		var modifiers []Modifier
+		if spec.hasDefault && spec.metavar != "" {
+			modifiers = append(modifiers, Default(spec.defaultVal))
+		}
+
+		switch {
+		case spec.metavar == "":
+			spec.opt = app.AddFlag(names, new(int), spec.help, modifiers...)
+		case spec.repeated:
+			modifiers = append(modifiers, Metavar(spec.metavar))
+			spec.opt = app.AddOption(names, new([]string), spec.help, modifiers...)
+		default:
+			modifiers = append(modifiers, Metavar(spec.metavar))
+			spec.opt = app.AddOption(names, new(string), spec.help, modifiers...)
+		}
+	}
+
+	for _, node := range p.positionals {
+		if node.repeated {
+			node.opt = app.AddOption(node.name, new([]string), "")
+		} else {
+			node.opt = app.AddOption(node.name, new(string), "")
+		}
+	}
+}
+
+// applyRules marks the elements of nodes which are required, and makes the alternatives of
+// groups exclude each other
+func (p *usageParser) applyRules(nodes []*usageNode, required bool, excluded map[[2]*Option]bool) {
+	for _, node := range nodes {
+		if node.kind != usageGroup {
+			if opt := node.option(); opt != nil && required {
+				Required()(opt)
+			}
+			continue
+		}
+
+		for _, alternative := range node.alternatives {
+			p.applyRules(alternative, required && !node.optional && len(node.alternatives) == 1, excluded)
+		}
+
+		for i, alternative := range node.alternatives {
+			for _, other := range node.alternatives[i+1:] {
+				p.exclude(alternative, other, excluded)
+			}
+		}
+	}
+}
+
+// exclude makes the options only found in one of the alternatives a and b exclude the options
+// only found in the other
+func (p *usageParser) exclude(a []*usageNode, b []*usageNode, excluded map[[2]*Option]bool) {
+	optionsA := p.options(a)
+	optionsB := p.options(b)
+
+	for _, optA := range optionsA {
+		for _, optB := range optionsB {
+			if containsOption(optionsA, optB) || containsOption(optionsB, optA) {
+				continue
+			}
+
+			if excluded[[2]*Option{optA, optB}] || excluded[[2]*Option{optB, optA}] {
+				continue
+			}
+
+			excluded[[2]*Option{optA, optB}] = true
+			Excludes(optB)(optA)
+		}
+	}
+}
+
+// option returns the option added for an option or positional argument node, which is nil
+// for the options provided by cligo itself
+func (node *usageNode) option() *Option {
+	if node.spec != nil {
+		return node.spec.opt
+	}
+
+	return node.opt
+}
+
+// options returns all of the options added for nodes and their children
+func (p *usageParser) options(nodes []*usageNode) []*Option {
+	var result []*Option
+	for _, node := range nodes {
+		if node.kind != usageGroup {
+			if opt := node.option(); opt != nil {
+				result = append(result, opt)
+			}
+			continue
+		}
+
+		for _, alternative := range node.alternatives {
+			result = append(result, p.options(alternative)...)
+		}
+	}
+
+	return result
+}
+
+func containsOption(options []*Option, opt *Option) bool {
+	for _, o := range options {
+		if o == opt {
+			return true
+		}
+	}
+
+	return false
+}
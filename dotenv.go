@@ -0,0 +1,351 @@
+package cligo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type dotenvValue struct {
+	value string
+	file  string
+	line  int
+}
+
+// WithDotEnv causes the application to load the given dotenv files (see LoadDotEnv) each time
+// the command line is parsed. Files which do not exist are silently ignored.
+func WithDotEnv(paths ...string) AppOption {
+	return func(app *App) {
+		app.dotenvPaths = append(app.dotenvPaths, paths...)
+	}
+}
+
+// LoadDotEnv reads environment variables from the given dotenv files. The variables are used
+// when resolving options bound to environment variables with the Env modifier, but the real
+// environment of the process is not modified, and takes priority over variables from dotenv
+// files. If a variable is defined by more than one file, the first definition is used.
+//
+// Each line of a file is of the form:
+//
+//	NAME=value
+//
+// optionally prefixed with "export". Blank lines and comments starting with # are ignored.
+// Values may be:
+//
+//   - unquoted, in which case they end at the end of the line or at a # preceded by whitespace
+//   - single quoted, in which case they are used literally
+//   - double quoted, in which case the escapes \n, \r, \t, \", \$ and \\ are supported
+//
+// Quoted values may span multiple lines. In unquoted and double quoted values, references to
+// variables in the form ${NAME} or $NAME are replaced with the value of the variable.
+func (a *App) LoadDotEnv(paths ...string) error {
+	for _, path := range paths {
+		if err := loadDotEnv(path, a.dotenv, a.getenv); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadDotEnv parses the dotenv file at path and adds the variables to env, if they are not
+// already defined there. getenv is used to expand references to variables not defined in the file.
+func loadDotEnv(path string, env map[string]dotenvValue, getenv func(string) (string, Source, bool)) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	values, err := parseDotEnv(string(data), path, getenv)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range values {
+		if _, exists := env[name]; !exists {
+			env[name] = value
+		}
+	}
+
+	return nil
+}
+
+// getenv looks up an environment variable in the real environment, and then in the variables
+// loaded from dotenv files. The returned Source describes where the value was found.
+func (a App) getenv(name string) (string, Source, bool) {
+	if value, ok := a.lookupEnv(name); ok {
+		return value, Source{Kind: SourceEnv, Name: name}, true
+	}
+
+	for _, env := range []map[string]dotenvValue{a.dotenv, a.parsedDotEnv} {
+		if v, ok := env[name]; ok {
+			return v.value, Source{Kind: SourceEnv, Name: name, File: v.file, Line: v.line}, true
+		}
+	}
+
+	return "", Source{}, false
+}
+
+// loadDotEnvs loads the dotenv files given to WithDotEnv
+func (a *App) loadDotEnvs() error {
+	a.parsedDotEnv = make(map[string]dotenvValue)
+
+	for _, path := range a.dotenvPaths {
+		if err := loadDotEnv(path, a.parsedDotEnv, a.getenv); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type dotenvParser struct {
+	data   string
+	pos    int
+	line   int
+	file   string
+	values map[string]dotenvValue
+	getenv func(string) (string, Source, bool)
+}
+
+func parseDotEnv(data string, file string, getenv func(string) (string, Source, bool)) (map[string]dotenvValue, error) {
+	p := &dotenvParser{
+		data:   data,
+		line:   1,
+		file:   file,
+		values: make(map[string]dotenvValue),
+		getenv: getenv,
+	}
+
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.data) {
+			break
+		}
+
+		if err := p.parseLine(); err != nil {
+			return nil, err
+		}
+	}
+
+	return p.values, nil
+}
+
+func (p *dotenvParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%s:%d: %s", p.file, p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *dotenvParser) peek() byte {
+	if p.pos < len(p.data) {
+		return p.data[p.pos]
+	}
+	return 0
+}
+
+func (p *dotenvParser) next() byte {
+	ch := p.data[p.pos]
+	p.pos++
+	if ch == '\n' {
+		p.line++
+	}
+	return ch
+}
+
+// skipBlank skips whitespace, empty lines and comment lines
+func (p *dotenvParser) skipBlank() {
+	for p.pos < len(p.data) {
+		switch ch := p.peek(); {
+		case ch == '#':
+			p.skipLine()
+		case isSpace(ch) || ch == '\n':
+			p.next()
+		default:
+			return
+		}
+	}
+}
+
+func (p *dotenvParser) skipSpaces() {
+	for p.pos < len(p.data) && isSpace(p.peek()) {
+		p.next()
+	}
+}
+
+func (p *dotenvParser) skipLine() {
+	for p.pos < len(p.data) && p.next() != '\n' {
+	}
+}
+
+func (p *dotenvParser) parseName() string {
+	start := p.pos
+	for p.pos < len(p.data) && isNameChar(p.peek()) {
+		p.next()
+	}
+	return p.data[start:p.pos]
+}
+
+func (p *dotenvParser) parseLine() error {
+	line := p.line
+	name := p.parseName()
+
+	if name == "export" && isSpace(p.peek()) {
+		p.skipSpaces()
+		name = p.parseName()
+	}
+
+	if name == "" {
+		return p.errorf("expected a variable name")
+	}
+
+	p.skipSpaces()
+	if p.peek() != '=' {
+		return p.errorf("expected '=' after %s", name)
+	}
+	p.next()
+	p.skipSpaces()
+
+	var value string
+	var err error
+	switch p.peek() {
+	case '\'':
+		value, err = p.parseSingleQuoted()
+	case '"':
+		value, err = p.parseDoubleQuoted()
+	default:
+		value = p.parseUnquoted()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// only whitespace and a comment may follow a value
+	p.skipSpaces()
+	switch p.peek() {
+	case 0, '\n':
+	case '#':
+		p.skipLine()
+	default:
+		return p.errorf("unexpected characters after the value of %s", name)
+	}
+
+	p.values[name] = dotenvValue{value: value, file: p.file, line: line}
+	return nil
+}
+
+func (p *dotenvParser) parseSingleQuoted() (string, error) {
+	line := p.line
+	p.next()
+
+	start := p.pos
+	for p.pos < len(p.data) {
+		if p.next() == '\'' {
+			return p.data[start : p.pos-1], nil
+		}
+	}
+
+	return "", fmt.Errorf("%s:%d: unterminated single quoted value", p.file, line)
+}
+
+func (p *dotenvParser) parseDoubleQuoted() (string, error) {
+	line := p.line
+	p.next()
+
+	var sb strings.Builder
+	for p.pos < len(p.data) {
+		ch := p.next()
+		switch ch {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if p.pos >= len(p.data) {
+				break
+			}
+
+			switch esc := p.next(); esc {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case '"', '\\', '$':
+				sb.WriteByte(esc)
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(esc)
+			}
+		case '$':
+			sb.WriteString(p.parseReference())
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+
+	return "", fmt.Errorf("%s:%d: unterminated double quoted value", p.file, line)
+}
+
+func (p *dotenvParser) parseUnquoted() string {
+	var sb strings.Builder
+	for p.pos < len(p.data) {
+		ch := p.peek()
+		if ch == '\n' || (ch == '#' && p.pos > 0 && isSpace(p.data[p.pos-1])) {
+			break
+		}
+
+		p.next()
+		if ch == '$' {
+			sb.WriteString(p.parseReference())
+		} else {
+			sb.WriteByte(ch)
+		}
+	}
+
+	return strings.TrimRight(sb.String(), " \t\r")
+}
+
+// parseReference parses a variable reference following a $ and returns its value. If the $
+// does not start a reference, it is returned unchanged.
+func (p *dotenvParser) parseReference() string {
+	braced := p.peek() == '{'
+	start := p.pos
+	if braced {
+		p.next()
+	}
+
+	name := p.parseName()
+	if name == "" || (braced && p.peek() != '}') {
+		p.pos = start
+		return "$"
+	}
+
+	if braced {
+		p.next()
+	}
+
+	return p.lookup(name)
+}
+
+func (p *dotenvParser) lookup(name string) string {
+	if value, _, ok := p.getenv(name); ok {
+		return value
+	}
+
+	if v, ok := p.values[name]; ok {
+		return v.value
+	}
+
+	return ""
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\r'
+}
+
+func isNameChar(ch byte) bool {
+	return ch == '_' ||
+		(ch >= 'a' && ch <= 'z') ||
+		(ch >= 'A' && ch <= 'Z') ||
+		(ch >= '0' && ch <= '9')
+}
@@ -14,4 +14,5 @@ var (
 	ErrEndOfArguments   = errors.New("end of arguments")
 	ErrDuplicateOption  = errors.New("duplicate option")
 	ErrHelpRequested    = errors.New("help requested")
+	ErrVersionRequested = errors.New("version requested")
 )
@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/eteran/cligo"
 )
@@ -23,13 +22,7 @@ func main() {
 	app.AddOption("-f,--file", &filename, "filename", cligo.Required(), cligo.AddValidator(cligo.ExistingFile()))
 	app.AddFlag("-v,--verbose", &verbose, "increase verbosity")
 
-	if err := app.ParseStrict(); err != nil {
-		fmt.Println(err)
-		os.Exit(0)
-
-	}
-
-	if err := Run(filename, verbose); err != nil {
-		panic(err)
-	}
+	app.Main(func() error {
+		return Run(filename, verbose)
+	})
 }
@@ -0,0 +1,103 @@
+package cligo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Exit codes returned by App.Exit. ExitUsage follows the EX_USAGE convention of sysexits.h.
+const (
+	ExitSuccess = 0
+	ExitFailure = 1
+	ExitUsage   = 64
+)
+
+// ExitCoder is implemented by errors which carry their own process exit code.
+// Errors returned by the function passed to App.Main (or passed to App.Exit) which
+// implement this interface, directly or wrapped, will result in the given exit code.
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// UsageError wraps errors which are the result of the command line being malformed, such as
+// unknown options, missing parameters or failed validation.
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+type exitError struct {
+	err  error
+	code int
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+func (e *exitError) ExitCode() int {
+	return e.code
+}
+
+// NewExitError returns an error wrapping err which will cause App.Exit to return code.
+func NewExitError(err error, code int) error {
+	return &exitError{err: err, code: code}
+}
+
+// Exit prints err (if any) to the application's stderr and returns the conventional exit code for it:
+//
+//   - 0 if err is nil or indicates that help or the version was requested
+//   - the code reported by err if it implements ExitCoder
+//   - 64 (EX_USAGE) if err is the result of a bad command line
+//   - 1 for all other errors
+func (a App) Exit(err error) int {
+	if err == nil || isInformational(err) {
+		return ExitSuccess
+	}
+
+	var coder ExitCoder
+	if errors.As(err, &coder) {
+		code := coder.ExitCode()
+		if code != ExitSuccess {
+			fmt.Fprintln(a.stderr, err)
+		}
+		return code
+	}
+
+	fmt.Fprintln(a.stderr, err)
+
+	var usageErr *UsageError
+	if errors.As(err, &usageErr) {
+		return ExitUsage
+	}
+
+	return ExitFailure
+}
+
+// Main strictly parses os.Args, calls run if parsing was successful and then terminates the
+// application using the exit code determined by Exit. It is intended to be the entire body
+// of a main function:
+//
+//	app.Main(func() error {
+//		return Run(filename, verbose)
+//	})
+func (a *App) Main(run func() error) {
+	err := a.parseArgsStrict(os.Args[1:])
+	if err == nil {
+		err = run()
+	}
+
+	a.exitFunc(a.Exit(err))
+}
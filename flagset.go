@@ -0,0 +1,65 @@
+package cligo
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// FromFlagSet creates an application with an option for each flag defined in fs, to ease the
+// migration of programs using the flag package. The flags' values are set using their
+// flag.Value, and their DefValue is displayed as the default in the usage string. Flags whose
+// value is a bool, or implements IsBoolFlag, become flags rather than options.
+//
+// aliases maps the names of flags to additional short names, for example {"verbose": "v"}.
+// Flags whose names are a single character are also short options, so -n 5, -n5, -n=5 and --n 5
+// are all accepted. The application accepts long options with a single dash, as the flag package
+// does (see WithSingleDashLong), so existing command lines continue to work. Values set by the application are not recorded by fs, so
+// fs.Visit does not report them.
+func FromFlagSet(fs *flag.FlagSet, aliases map[string]string, options ...AppOption) *App {
+	options = append([]AppOption{WithSingleDashLong()}, options...)
+	if fs.Name() != "" {
+		options = append([]AppOption{WithProgramName(fs.Name())}, options...)
+	}
+
+	app := NewApp(options...)
+
+	for name := range aliases {
+		if fs.Lookup(name) == nil {
+			panic(fmt.Sprintf("no flag named %s", name))
+		}
+	}
+
+	fs.VisitAll(func(f *flag.Flag) {
+		names := "--" + f.Name
+		if len(f.Name) == 1 {
+			names = "-" + f.Name + "," + names
+		}
+
+		if alias, ok := aliases[f.Name]; ok {
+			names = "-" + alias + "," + names
+		}
+
+		metavar, help := flag.UnquoteUsage(f)
+		v, isBool := f.Value.(boolValue)
+		isBool = isBool && v.IsBoolFlag()
+
+		var modifiers []Modifier
+		if f.DefValue != "" && !(isBool && f.DefValue == "false") {
+			modifiers = append(modifiers, DefaultString(f.DefValue))
+		}
+
+		if isBool {
+			app.AddFlag(names, f.Value, help, modifiers...)
+			return
+		}
+
+		if metavar != "" {
+			modifiers = append(modifiers, Metavar(strings.ToUpper(metavar)))
+		}
+
+		app.AddOption(names, f.Value, help, modifiers...)
+	})
+
+	return app
+}
@@ -0,0 +1,99 @@
+package cligo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FromGetopt creates an application from option descriptions in the style of getopt, to ease
+// the porting of shell scripts. optstring lists the short options, each followed by ":" if it
+// takes a value, or "::" if its value is optional. A leading "+" requests POSIXLY_CORRECT
+// parsing, and a leading ":" is ignored. longopts lists the long options in the same way,
+// for example "verbose", "file:" and "color::".
+//
+// The options are not bound to variables. Their values can be retrieved after parsing with
+// Lookup and Value, which is a *string for options taking a value, and a *int counting the
+// occurrences for flags. Since cligo provides -h and --help itself, they are ignored.
+func FromGetopt(optstring string, longopts []string, options ...AppOption) (*App, error) {
+	for len(optstring) > 0 && (optstring[0] == '+' || optstring[0] == ':') {
+		if optstring[0] == '+' {
+			options = append(options, WithPosixlyCorrect())
+		}
+		optstring = optstring[1:]
+	}
+
+	app := NewApp(options...)
+	seen := make(map[string]bool)
+
+	add := func(name string, colons int) error {
+		if seen[name] {
+			return fmt.Errorf("%w: %s", ErrDuplicateOption, name)
+		}
+		seen[name] = true
+
+		switch {
+		case name == "-h" || name == "--help":
+		case colons == 0:
+			app.AddFlag(name, new(int), "")
+		case colons == 1:
+			app.AddOption(name, new(string), "")
+		default:
+			app.AddOption(name, new(string), "", OptionalValue(""))
+		}
+		return nil
+	}
+
+	for i := 0; i < len(optstring); {
+		ch := optstring[i]
+		i++
+
+		if !isOptionChar(ch) {
+			return nil, fmt.Errorf("invalid option character %q in optstring", ch)
+		}
+
+		colons := 0
+		for i < len(optstring) && optstring[i] == ':' && colons < 2 {
+			colons++
+			i++
+		}
+
+		if err := add("-"+string(ch), colons); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, long := range longopts {
+		name := strings.TrimRight(long, ":")
+		colons := len(long) - len(name)
+
+		if !isLongOptionName(name) || colons > 2 {
+			return nil, fmt.Errorf("invalid long option %q", long)
+		}
+
+		if err := add("--"+name, colons); err != nil {
+			return nil, err
+		}
+	}
+
+	return app, nil
+}
+
+func isOptionChar(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') ||
+		(ch >= 'A' && ch <= 'Z') ||
+		(ch >= '0' && ch <= '9')
+}
+
+func isLongOptionName(name string) bool {
+	if name == "" || name[0] == '-' {
+		return false
+	}
+
+	for i := 0; i < len(name); i++ {
+		if !isOptionChar(name[i]) && name[i] != '-' && name[i] != '_' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -1,5 +1,9 @@
 package cligo
 
+import (
+	"math"
+)
+
 type Modifier func(opt *Option)
 
 // Needs specifies that the associated option requires that the option referred to by dep also be set.
@@ -17,7 +21,8 @@ func Excludes(dep *Option) Modifier {
 	}
 }
 
-// Required specifies that the associated option MUST be set.
+// Required specifies that the associated option MUST be set. An option which also has a
+// Default is satisfied by its default value when it is not given.
 func Required() Modifier {
 	return func(opt *Option) {
 		opt.isRequired = true
@@ -62,6 +67,29 @@ func DefaultString(value string) Modifier {
 	}
 }
 
+// Default sets a value which is assigned to the associated option when no other source provides
+// one. Unlike values from the command line, environment or configuration files, a default value
+// does not count as an occurrence of the option, so Exists will still return false and the
+// option's trigger is not called. If no DefaultString has been set, value is also displayed as
+// the default during usage statements.
+func Default(value string) Modifier {
+	return func(opt *Option) {
+		opt.defaultValue = value
+		opt.hasDefault = true
+		if opt.defaultString == "" {
+			opt.defaultString = value
+		}
+	}
+}
+
+// Env specifies environment variables which can provide the value of the associated option.
+// If several are given, the first one which is set is used.
+func Env(names ...string) Modifier {
+	return func(opt *Option) {
+		opt.envNames = append(opt.envNames, names...)
+	}
+}
+
 // CaptureDefault associates the current value of the variable linked to this option as the
 // default value string to use during usage statements.
 // For example:
@@ -78,6 +106,84 @@ func CaptureDefault() Modifier {
 	}
 }
 
+// Metavar sets the placeholder used for the value of the associated option in the usage string.
+// By default, this is derived from the type of the bound variable, for example TEXT or NUMBER.
+func Metavar(name string) Modifier {
+	return func(opt *Option) {
+		opt.metavarName = name
+	}
+}
+
+// OptionalValue specifies that the value of the associated option may be omitted, in which case
+// implicit is used. The value must then be attached to the option, as in --color=never or -cnever,
+// so that --color never sets the option to implicit and leaves never as the next argument.
+// The usage string shows the option as --color[=TEXT].
+func OptionalValue(implicit string) Modifier {
+	return func(opt *Option) {
+		opt.implicit = implicit
+		opt.hasImplicit = true
+	}
+}
+
+// Arity specifies that the associated option takes n values each time it is given, for example
+// --resize 800 600. The option must be bound to a slice, to which the values are appended, or
+// to an array, whose elements are set in order. Values from environment variables, configuration
+// files and defaults are separated by whitespace.
+func Arity(n int) Modifier {
+	return ArityRange(n, n)
+}
+
+// ArityRange specifies that the associated option takes between min and max values each time
+// it is given. After the first min values, the option stops taking values at the first argument
+// which looks like an option. See Arity.
+func ArityRange(min int, max int) Modifier {
+	return func(opt *Option) {
+		if min < 0 || max < 1 || min > max {
+			panic("invalid arity")
+		}
+
+		opt.arityMin = min
+		opt.arityMax = max
+	}
+}
+
+// Expected specifies how many arguments the associated positional argument takes. If max is
+// negative, there is no limit. A positional argument which takes more than one argument must be
+// bound to a slice or array, and takes as many of the remaining arguments as it can while leaving
+// enough for the positional arguments which follow it, so that "SRC... DST" works as expected.
+// If min is greater than zero, the positional argument must be given.
+//
+// Positional arguments bound to a slice take any number of arguments by default.
+func Expected(min int, max int) Modifier {
+	return func(opt *Option) {
+		if max < 0 {
+			max = math.MaxInt
+		}
+
+		if min < 0 || max < 1 || min > max {
+			panic("invalid number of expected arguments")
+		}
+
+		opt.arityMin = min
+		opt.arityMax = max
+	}
+}
+
+// Secret specifies that the value of the associated option is sensitive, such as a password.
+// The value is masked wherever cligo would display it, such as defaults in the usage string
+// and provenance reports.
+//
+// Secret options also support reading their value from a file, following the convention used
+// by Docker secrets. For an option named --db-password bound to the environment variable
+// DB_PASSWORD, the value may instead be given as the path of a file with --db-password-file,
+// DB_PASSWORD_FILE, or db-password-file in a configuration file. Trailing newlines are removed
+// from the contents of the file.
+func Secret() Modifier {
+	return func(opt *Option) {
+		opt.isSecret = true
+	}
+}
+
 // AddValidator adds a validator to a given option.
 func AddValidator(v Validator) Modifier {
 	return func(opt *Option) {
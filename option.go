@@ -1,10 +1,14 @@
 package cligo
 
 import (
+	"flag"
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
+
+	"golang.org/x/exp/slices"
 )
 
 type Signed interface {
@@ -34,9 +38,11 @@ type Option struct {
 
 	owner         *App
 	count         int
+	source        Source
 	ptr           any
 	description   string
 	defaultString string
+	metavarName   string
 	group         string
 	isFlag        bool
 	isRequired    bool
@@ -46,15 +52,46 @@ type Option struct {
 	validators    []Validator
 	onSet         Callback
 	setter        setterFunc
+	envNames      []string
+	defaultValue  string
+	hasDefault    bool
+	isSecret      bool
+	preParse      bool
+	isConfig      bool
+	isProfile     bool
+	implicit      string
+	hasImplicit   bool
+	arityMin      int
+	arityMax      int
+
+	// true once the option has been assigned a value during the current parse
+	isSet bool
+}
 
-	// TODO(eteran):
-	// envname
+// boolValue is a flag.Value which behaves like a bool, as recognized by the flag package
+type boolValue interface {
+	flag.Value
+	IsBoolFlag() bool
 }
 
-type setterFunc func(opt *Option, value string, isNegated bool) error
+type setterFunc func(opt *Option, values []string, isNegated bool) error
 
 type Callback func(opt *Option) error
 
+// set assigns the values of a single occurrence of the option, recording where they came from.
+// Options have more than one value only if they have an arity, see Arity.
+func (opt *Option) set(values []string, isNegated bool, source Source) error {
+	// the source is visible to triggers run by the setter, but is only kept if it succeeds
+	previous := opt.source
+	opt.source = source
+	if err := opt.setter(opt, values, isNegated); err != nil {
+		opt.source = previous
+		return err
+	}
+
+	return nil
+}
+
 func (opt Option) Value() any {
 	return opt.ptr
 }
@@ -79,6 +116,148 @@ func (opt Option) IsPositionalOnly() bool {
 		len(opt.sNamesNeg) == 0
 }
 
+// LongNames returns the long names of the option without the leading dashes.
+func (opt Option) LongNames() []string {
+	return slices.Clone(opt.lNames)
+}
+
+// ShortNames returns the short names of the option without the leading dash.
+func (opt Option) ShortNames() []string {
+	return slices.Clone(opt.sNames)
+}
+
+// NegatedLongNames returns the negated long names of the option without the leading dashes.
+func (opt Option) NegatedLongNames() []string {
+	return slices.Clone(opt.lNamesNeg)
+}
+
+// NegatedShortNames returns the negated short names of the option without the leading dash.
+func (opt Option) NegatedShortNames() []string {
+	return slices.Clone(opt.sNamesNeg)
+}
+
+// PositionalName returns the positional name of the option, or an empty string if it is not positional.
+func (opt Option) PositionalName() string {
+	return opt.pName
+}
+
+// Help returns the help string of the option.
+func (opt Option) Help() string {
+	return opt.description
+}
+
+// Group returns the name of the group the option is displayed in.
+func (opt Option) Group() string {
+	return opt.group
+}
+
+// DefaultString returns the default value displayed in the usage string, if any.
+// For secret options, the value is masked.
+func (opt Option) DefaultString() string {
+	return opt.displayDefault()
+}
+
+// IsSecret returns true if the option's value must not be displayed.
+func (opt Option) IsSecret() bool {
+	return opt.isSecret
+}
+
+// displayDefault returns the default value to display, masking it if the option is secret
+func (opt Option) displayDefault() string {
+	if opt.isSecret && opt.defaultString != "" {
+		return secretMask
+	}
+	return opt.defaultString
+}
+
+// displayValue returns the current value to display, masking it if the option is secret
+func (opt Option) displayValue() string {
+	if opt.isSecret {
+		return secretMask
+	}
+	return getValue(opt.ptr)
+}
+
+// Metavar returns the placeholder used for the option's value in the usage string.
+func (opt Option) Metavar() string {
+	return opt.metavar()
+}
+
+// EnvNames returns the names of the environment variables the option can be set by.
+func (opt Option) EnvNames() []string {
+	return slices.Clone(opt.envNames)
+}
+
+// IsRequired returns true if the option must be set.
+func (opt Option) IsRequired() bool {
+	return opt.isRequired
+}
+
+// IsFlag returns true if the option is a flag rather than an option which takes a value.
+func (opt Option) IsFlag() bool {
+	return opt.isFlag
+}
+
+// Arity returns the minimum and maximum number of values the option takes each time it is given,
+// as set by Arity, ArityRange or Expected. Options without an arity take one value, and flags
+// take none. If there is no maximum, max is math.MaxInt.
+func (opt Option) Arity() (min int, max int) {
+	switch {
+	case opt.hasArity():
+		return opt.arityMin, opt.arityMax
+	case opt.isFlag:
+		return 0, 0
+	default:
+		return 1, 1
+	}
+}
+
+// hasArity returns true if the option was given an arity with Arity or ArityRange
+func (opt Option) hasArity() bool {
+	return opt.arityMax != 0
+}
+
+// checkArity returns an error if n is not an acceptable number of values for the option
+func (opt Option) checkArity(n int) error {
+	expected := strconv.Itoa(opt.arityMin)
+	switch {
+	case opt.arityMax == math.MaxInt:
+		expected = "at least " + expected
+	case opt.arityMax != opt.arityMin:
+		expected = fmt.Sprintf("%d to %d", opt.arityMin, opt.arityMax)
+	}
+
+	switch {
+	case n < opt.arityMin:
+		return fmt.Errorf("%w: %s expects %s values, got %d", ErrMissingParameter, opt.displayName(), expected, n)
+	case n > opt.arityMax:
+		return fmt.Errorf("%s expects %s values, got %d", opt.displayName(), expected, n)
+	default:
+		return nil
+	}
+}
+
+// ImplicitValue returns the value set by OptionalValue, which is used when the option is given
+// without a value, and whether the option has one.
+func (opt Option) ImplicitValue() (string, bool) {
+	return opt.implicit, opt.hasImplicit
+}
+
+// IgnoresCase returns true if the option's names are matched case insensitively.
+func (opt Option) IgnoresCase() bool {
+	return opt.ignoreCase
+}
+
+// Needs returns the options which must also be set when this option is set.
+func (opt Option) Needs() []*Option {
+	return slices.Clone(opt.needs)
+}
+
+// Excludes returns the options which must not be set when this option is set.
+func (opt Option) Excludes() []*Option {
+	return slices.Clone(opt.excludes)
+}
+
 func (opt Option) canonicalName() string {
 
 	if len(opt.lNames) != 0 {
@@ -130,9 +309,86 @@ func getValue(ptr any) string {
 		return strconv.FormatBool(*p)
 	case *string:
 		return *p
+	case flag.Value:
+		return p.String()
 	default:
+		return getElements(ptr)
+	}
+}
+
+// getElements returns the elements of a bound slice or array separated by spaces
+func getElements(ptr any) string {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Ptr || (rv.Elem().Kind() != reflect.Slice && rv.Elem().Kind() != reflect.Array) {
 		return ""
 	}
+
+	elems := rv.Elem()
+	values := make([]string, 0, elems.Len())
+	for i := 0; i < elems.Len(); i++ {
+		values = append(values, getValue(elems.Index(i).Addr().Interface()))
+	}
+
+	return strings.Join(values, " ")
+}
+
+// setValues assigns values to the bound variable. Each value is appended to a bound slice,
+// fills the next element of a bound array, or replaces the value of any other variable.
+func setValues(ptr any, values []string) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() == reflect.Ptr && rv.Elem().Kind() == reflect.Array {
+		elems := rv.Elem()
+		if len(values) > elems.Len() {
+			return fmt.Errorf("expected at most %d values, got %d", elems.Len(), len(values))
+		}
+
+		for i, value := range values {
+			if err := setValue(elems.Index(i).Addr().Interface(), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, value := range values {
+		if err := setValue(ptr, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearSlice empties a bound slice the first time the option is set during a parse, so that
+// the values given replace the initial contents of the slice rather than being appended to them
+func (opt *Option) clearSlice() {
+	if opt.isSet {
+		return
+	}
+	opt.isSet = true
+
+	if _, isValue := opt.ptr.(flag.Value); isValue || opt.ptr == nil {
+		return
+	}
+
+	if rv := reflect.ValueOf(opt.ptr); rv.Kind() == reflect.Ptr && rv.Elem().Kind() == reflect.Slice {
+		rv.Elem().Set(reflect.Zero(rv.Elem().Type()))
+	}
+}
+
+// appendValue appends value to the slice pointed to by ptr
+func appendValue(ptr any, value string) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return ErrUnsupportedType
+	}
+
+	elem := reflect.New(rv.Elem().Type().Elem())
+	if err := setValue(elem.Interface(), value); err != nil {
+		return err
+	}
+
+	rv.Elem().Set(reflect.Append(rv.Elem(), elem.Elem()))
+	return nil
 }
 
 func setValue(ptr any, value string) error {
@@ -218,8 +474,10 @@ func setValue(ptr any, value string) error {
 		*p = f
 	case *string:
 		*p = value
+	case flag.Value:
+		return p.Set(value)
 	default:
-		return ErrUnsupportedType
+		return appendValue(ptr, value)
 	}
 	return nil
 }
@@ -279,6 +537,8 @@ func incrementFlag(ptr any, isNegated bool) error {
 		*p++
 	case *bool:
 		*p = true
+	case flag.Value:
+		return p.Set("true")
 	default:
 		return ErrUnsupportedType
 	}
@@ -310,13 +570,26 @@ func decrementFlag(ptr any) error {
 		*p--
 	case *bool:
 		*p = false
+	case flag.Value:
+		return p.Set("false")
 	default:
 		return ErrUnsupportedType
 	}
 	return nil
 }
 
-func (opt *Option) format() string {
+// metavar returns the placeholder used for the option's value in the usage string
+func (opt *Option) metavar() string {
+	if opt.metavarName != "" {
+		return opt.metavarName
+	}
+
+	return pointerType(opt.ptr)
+}
+
+// names returns the option's names, with their leading dashes, in the order they are
+// displayed in the usage string
+func (opt *Option) names() []string {
 	nameList := make([]string, 0, len(opt.sNames)+len(opt.lNames)+len(opt.sNamesNeg)+len(opt.lNamesNeg))
 	for _, str := range opt.sNames {
 		nameList = append(nameList, "-"+str)
@@ -334,38 +607,49 @@ func (opt *Option) format() string {
 		nameList = append(nameList, "--"+str)
 	}
 
-	names := strings.Join(nameList, ",")
-	names = names + pointerType(opt.ptr)
-
-	if opt.defaultString != "" {
-		names = names + fmt.Sprintf(" [%s]", opt.defaultString)
+	if opt.isSecret {
+		for _, str := range opt.lNames {
+			nameList = append(nameList, "--"+str+secretFileSuffix)
+		}
 	}
 
-	if opt.isRequired {
-		names = names + " REQUIRED"
-	}
-	return fmt.Sprintf("  %-30s %s", names, opt.description)
+	return nameList
 }
 
-func (opt *Option) formatPositional() string {
-	name := opt.pName
-	name = name + pointerType(opt.ptr)
+func (opt *Option) label(names string) string {
+	if metavar := opt.metavar(); metavar != "" {
+		switch {
+		case opt.hasImplicit:
+			names = names + "[=" + metavar + "]"
+		case opt.hasArity():
+			names = names + strings.Repeat(" "+metavar, opt.arityMin)
+			if opt.arityMax > opt.arityMin {
+				names = names + " [" + metavar + "...]"
+			}
+		default:
+			names = names + " " + metavar
+		}
+	}
 
-	if opt.defaultString != "" {
-		name = name + fmt.Sprintf(" [%s]", opt.defaultString)
+	if defaultString := opt.displayDefault(); defaultString != "" {
+		names = names + fmt.Sprintf(" [%s]", defaultString)
 	}
 
 	if opt.isRequired {
-		name = name + " REQUIRED"
+		names = names + " REQUIRED"
+	}
+
+	if len(opt.envNames) != 0 {
+		names = names + fmt.Sprintf(" (env: %s)", strings.Join(opt.envNames, ","))
 	}
-	return fmt.Sprintf("  %-30s %s", name, opt.description)
+	return names
 }
 
 func NewOption(name string, ptr any, help string, modifiers ...Modifier) *Option {
-	if ptr != nil {
+	if _, isValue := ptr.(flag.Value); ptr != nil && !isValue {
 		rv := reflect.ValueOf(ptr)
 		if rv.Kind() != reflect.Ptr {
-			panic("bound variables must be pointers")
+			panic("bound variables must be pointers or implement flag.Value")
 		}
 	}
 
@@ -374,16 +658,19 @@ func NewOption(name string, ptr any, help string, modifiers ...Modifier) *Option
 		isFlag:      false,
 		group:       "Options",
 		ptr:         ptr,
-		setter: func(opt *Option, v string, isNegated bool) error {
+		setter: func(opt *Option, values []string, isNegated bool) error {
 
-			for _, validator := range opt.validators {
-				if err := validator(v); err != nil {
-					return err
+			for _, v := range values {
+				for _, validator := range opt.validators {
+					if err := validator(v); err != nil {
+						return opt.maskError(err)
+					}
 				}
 			}
 
-			if err := setOption(opt.ptr, v, isNegated); err != nil {
-				return err
+			opt.clearSlice()
+			if err := setOption(opt.ptr, values, isNegated); err != nil {
+				return opt.maskError(err)
 			}
 
 			opt.count++
@@ -400,6 +687,14 @@ func NewOption(name string, ptr any, help string, modifiers ...Modifier) *Option
 		mod(opt)
 	}
 
+	if opt.arityMax > 1 && ptr != nil {
+		switch reflect.Indirect(reflect.ValueOf(ptr)).Kind() {
+		case reflect.Slice, reflect.Array:
+		default:
+			panic("options taking more than one value must be bound to a slice or array")
+		}
+	}
+
 	name = strings.TrimSpace(name)
 	names := strings.Split(name, ",")
 	for _, optionName := range names {
@@ -436,6 +731,12 @@ func NewOption(name string, ptr any, help string, modifiers ...Modifier) *Option
 		}
 	}
 
+	// positional arguments bound to a slice take all of the arguments they can by default
+	if opt.IsPositionalOnly() && !opt.hasArity() && ptr != nil && reflect.Indirect(reflect.ValueOf(ptr)).Kind() == reflect.Slice {
+		opt.arityMin = 0
+		opt.arityMax = math.MaxInt
+	}
+
 	return opt
 }
 
@@ -448,16 +749,18 @@ func NewFlag(name string, ptr any, help string, modifiers ...Modifier) *Option {
 		isFlag:      true,
 		ptr:         ptr,
 		group:       "Options",
-		setter: func(opt *Option, v string, isNegated bool) error {
+		setter: func(opt *Option, values []string, isNegated bool) error {
 
-			for _, validator := range opt.validators {
-				if err := validator(v); err != nil {
-					return err
+			for _, v := range values {
+				for _, validator := range opt.validators {
+					if err := validator(v); err != nil {
+						return err
+					}
 				}
-			}
 
-			if err := setFlag(opt.ptr, v, isNegated); err != nil {
-				return err
+				if err := setFlag(opt.ptr, v, isNegated); err != nil {
+					return err
+				}
 			}
 
 			opt.count++
@@ -515,6 +818,11 @@ func NewFlag(name string, ptr any, help string, modifiers ...Modifier) *Option {
 }
 
 func ensureIntegralPointer(ptr any) {
+	// flag.Value implementations which behave like a bool are accepted, as the flag package does
+	if v, isBoolValue := ptr.(boolValue); isBoolValue && v.IsBoolFlag() {
+		return
+	}
+
 	if ptr != nil {
 		rv := reflect.ValueOf(ptr)
 		if rv.Kind() != reflect.Ptr {
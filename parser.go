@@ -0,0 +1,486 @@
+package cligo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// An assignment is a value for an option found in one of the sources of values
+type assignment struct {
+	opt       *Option
+	value     string
+	isNegated bool
+	source    Source
+
+	// if true, value is the path of a file containing the value, see Secret
+	fromFile bool
+
+	// the values of an option with an arity found on the command line, see Arity
+	values []string
+}
+
+// parser holds the state of a single parse of the command line. Values found on the
+// command line are collected rather than assigned immediately so that they can be
+// resolved against the other sources of values.
+type parser struct {
+	app         *App
+	total       int
+	assignments []assignment
+
+	// the source of each argument, before its index is known
+	origins []Source
+
+	// configuration loaded during this parse by WithConfigDiscovery and options added
+	// with AddConfigOption
+	config       []configEntry
+	configFiles  []string
+	configLoaded bool
+
+	// the arguments following "--", if the application has a passthrough, see AddPassthrough
+	passthrough []string
+
+	// unknown options and their likely values, see WithAllowUnknown
+	unknown []string
+}
+
+// source returns the source of the remaining argument args[0]
+func (p *parser) source(args []string) Source {
+	index := p.total - len(args)
+
+	source := Source{Kind: SourceArgv}
+	if index < len(p.origins) {
+		source = p.origins[index]
+	}
+
+	source.Index = index
+	return source
+}
+
+func (p *parser) assign(opt *Option, value string, isNegated bool, source Source) {
+	p.assignments = append(p.assignments, assignment{
+		opt:       opt,
+		value:     value,
+		isNegated: isNegated,
+		source:    source,
+	})
+}
+
+// parseOneLong parses the long option arg, whose name (and possibly value) without the
+// leading dashes is name
+func (p *parser) parseOneLong(arg string, name string, args []string, source Source) ([]string, error) {
+	/*
+		--file filename (space)
+		--file=filename (equals)
+		--long_flag=true (long flag with equals to override default value)
+		--long (long flag)
+		--color, --color=never (optional value)
+		--resize 800 600 (arity)
+	*/
+	param := ""
+	hasParam := false
+
+	if strings.Contains(name, "=") {
+		parts := strings.SplitN(name, "=", 2)
+		name = parts[0]
+		param = parts[1]
+		hasParam = true
+	}
+
+	opt, isNegated, exists := p.app.findLongOption(name)
+	fromFile := false
+	if !exists {
+		opt, fromFile = p.app.findSecretFileOption(name)
+		exists = fromFile
+	}
+
+	if !exists {
+		if p.app.allowUnknown {
+			return p.collectUnknown(arg, hasParam, args), nil
+		}
+		return nil, fmt.Errorf("the following argument was not expected: %s\n%s", arg, ErrorSuffix)
+	}
+
+	if opt.hasArity() && !fromFile {
+		var values []string
+		if hasParam {
+			values = append(values, param)
+		}
+
+		var err error
+		values, args, err = p.consumeValues(opt, values, args)
+		if err != nil {
+			return nil, err
+		}
+
+		p.assignments = append(p.assignments, assignment{
+			opt:       opt,
+			isNegated: isNegated,
+			source:    source,
+			values:    values,
+		})
+		return args, nil
+	}
+
+	// the value of an option with an implicit value must be attached with =
+	optional := opt.hasImplicit && !fromFile
+	if optional && !hasParam {
+		param = opt.implicit
+	} else if !optional && !opt.isFlag && param == "" {
+		if len(args) == 0 {
+			return nil, ErrMissingParameter
+		}
+		param = args[0]
+		args = args[1:]
+	}
+
+	p.assignments = append(p.assignments, assignment{
+		opt:       opt,
+		value:     param,
+		isNegated: isNegated,
+		source:    source,
+		fromFile:  fromFile,
+	})
+	return args, nil
+}
+
+func (p *parser) parseOneShort(arg string, args []string, source Source) ([]string, error) {
+	/*
+		-a (flag)
+		-f filename (option)
+		-ffilename (no space required)
+		-abc (flags can be combined)
+		-abcf filename (flags and option can be combined)
+		-c, -cnever (optional value)
+		-f=filename, -x=false (with WithSingleDashLong, as in the flag package)
+	*/
+
+	name := arg[1:]
+
+	for i, ch := range name {
+		shortName := string(ch)
+
+		isLast := i == len(name)-1
+		attached := name[1+i:]
+		hasEquals := p.app.singleDashLong && strings.HasPrefix(attached, "=")
+		if hasEquals {
+			attached = attached[1:]
+		}
+
+		opt, isNegated, exists := p.app.findShortOption(shortName)
+		if !exists {
+			if p.app.allowUnknown {
+				// the rest of the argument is likely to be the value of the unknown option
+				return p.collectUnknown("-"+name[i:], !isLast, args), nil
+			}
+			return nil, fmt.Errorf("the following argument was not expected: %s\n%s", arg, ErrorSuffix)
+		}
+
+		if opt.isFlag && hasEquals {
+			p.assign(opt, attached, isNegated, source)
+			break
+		} else if opt.isFlag {
+			p.assign(opt, "", isNegated, source)
+		} else if opt.hasArity() {
+			var values []string
+			if !isLast {
+				values = append(values, attached)
+			}
+
+			var err error
+			values, args, err = p.consumeValues(opt, values, args)
+			if err != nil {
+				return nil, err
+			}
+
+			p.assignments = append(p.assignments, assignment{
+				opt:       opt,
+				isNegated: isNegated,
+				source:    source,
+				values:    values,
+			})
+			break
+		} else if isLast && opt.hasImplicit {
+			p.assign(opt, opt.implicit, isNegated, source)
+		} else if isLast {
+			if len(args) == 0 {
+				return nil, ErrMissingParameter
+			}
+
+			p.assign(opt, args[0], isNegated, source)
+			args = args[1:]
+		} else {
+			p.assign(opt, attached, isNegated, source)
+			break
+		}
+	}
+
+	return args, nil
+}
+
+// parseOneSingleDash parses arg as a long option if there is one with its name, and otherwise
+// as short options, see WithSingleDashLong
+func (p *parser) parseOneSingleDash(arg string, args []string, source Source) ([]string, error) {
+	/*
+		-verbose (long flag)
+		-file filename, -file=filename (long option)
+		-abc (short options)
+	*/
+	name := arg[1:]
+	longName, _, _ := strings.Cut(name, "=")
+
+	longOpt, _, isLong := p.app.findLongOption(longName)
+	if !isLong {
+		longOpt, isLong = p.app.findSecretFileOption(longName)
+	}
+
+	if !isLong {
+		return p.parseOneShort(arg, args, source)
+	}
+
+	// a single short flag with the same name as the long option is not ambiguous
+	if shortOpt, _, _ := p.app.findShortOption(name); p.app.isFlagCluster(name) && shortOpt != longOpt {
+		return nil, fmt.Errorf("the argument %s is ambiguous, it could be --%s or a combination of short options\n%s", arg, longName, ErrorSuffix)
+	}
+
+	return p.parseOneLong(arg, name, args, source)
+}
+
+func (p *parser) parseOne(args []string) ([]string, error) {
+	arg := args[0]
+	source := p.source(args)
+
+	var err error
+	switch {
+	case p.isBuiltin(arg, "-h", "--help"):
+		p.app.Usage()
+		return args, ErrHelpRequested
+	case p.isBuiltin(arg, p.app.versionNames()...):
+		p.app.printVersion()
+		return args, ErrVersionRequested
+	case arg == "--":
+		args = args[1:]
+		return args, ErrEndOfArguments
+	case isNegativeNumber(arg) && !p.app.hasDigitOption():
+		return args, ErrEndOfArguments
+	case strings.HasPrefix(arg, "--"):
+		args = args[1:]
+		args, err = p.parseOneLong(arg, arg[2:], args, source)
+		if err != nil {
+			return nil, source.wrap(err)
+		}
+	case strings.HasPrefix(arg, "-") && p.app.singleDashLong:
+		args = args[1:]
+		args, err = p.parseOneSingleDash(arg, args, source)
+		if err != nil {
+			return nil, source.wrap(err)
+		}
+	case strings.HasPrefix(arg, "-"):
+		args = args[1:]
+		args, err = p.parseOneShort(arg, args, source)
+		if err != nil {
+			return nil, source.wrap(err)
+		}
+	default:
+		return args, ErrEndOfArguments
+	}
+
+	return args, nil
+}
+
+// isBuiltin returns true if arg is one of the names of a built-in option. With WithSingleDashLong,
+// the long names are also accepted with a single dash, such as -help.
+func (p *parser) isBuiltin(arg string, names ...string) bool {
+	for _, name := range names {
+		if arg == name || (p.app.singleDashLong && strings.HasPrefix(name, "--") && arg == name[1:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// collectUnknown records an unknown option. With WithUnknownValues, the argument following it is
+// also recorded if the option has no attached value and the argument doesn't look like an
+// option, since it is likely to be the option's value. It returns the remaining arguments.
+func (p *parser) collectUnknown(arg string, hasValue bool, args []string) []string {
+	p.unknown = append(p.unknown, arg)
+	if p.app.unknownValues && !hasValue && len(args) > 0 && !p.looksLikeOption(args[0]) {
+		p.unknown = append(p.unknown, args[0])
+		args = args[1:]
+	}
+
+	return args
+}
+
+// consumeValues takes the values of an option with an arity from args, following the values
+// already attached to the option, and returns the values along with the remaining arguments
+func (p *parser) consumeValues(opt *Option, values []string, args []string) ([]string, []string, error) {
+	for len(values) < opt.arityMin {
+		if len(args) == 0 {
+			return nil, nil, opt.checkArity(len(values))
+		}
+
+		values = append(values, args[0])
+		args = args[1:]
+	}
+
+	for len(values) < opt.arityMax && len(args) > 0 && !p.looksLikeOption(args[0]) {
+		values = append(values, args[0])
+		args = args[1:]
+	}
+
+	return values, args, nil
+}
+
+// looksLikeOption returns true if arg would be parsed as an option or "--"
+func (p *parser) looksLikeOption(arg string) bool {
+	if isNegativeNumber(arg) && !p.app.hasDigitOption() {
+		return false
+	}
+
+	return len(arg) > 1 && arg[0] == '-'
+}
+
+// isNegativeNumber returns true if arg is a negative numeric literal such as -5, -1.5e3 or -0x10
+func isNegativeNumber(arg string) bool {
+	if len(arg) < 2 || arg[0] != '-' {
+		return false
+	}
+
+	number := arg[1:]
+	if !(number[0] >= '0' && number[0] <= '9') && number[0] != '.' {
+		return false
+	}
+
+	if _, err := strconv.ParseInt(number, 0, 64); err == nil {
+		return true
+	}
+
+	_, err := strconv.ParseFloat(number, 64)
+	return err == nil
+}
+
+// isAssigned returns true if a value for opt has already been found on the command line
+func (p *parser) isAssigned(opt *Option) bool {
+	for _, as := range p.assignments {
+		if as.opt == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePositional assigns args to the positional arguments in the order they were added.
+// Positional arguments which take several arguments take as many as they can, while leaving
+// enough for the minimum required by the positional arguments which follow them.
+func (p *parser) parsePositional(args []string, sources []Source) []string {
+
+	var positionals []*Option
+	for _, opt := range p.app.options {
+
+		if !opt.IsPositional() {
+			continue
+		}
+
+		if p.isAssigned(opt) {
+			continue
+		}
+
+		positionals = append(positionals, opt)
+	}
+
+	for i, opt := range positionals {
+
+		if len(args) == 0 {
+			break
+		}
+
+		if !opt.hasArity() {
+			p.assign(opt, args[0], false, sources[0])
+			args = args[1:]
+			sources = sources[1:]
+			continue
+		}
+
+		reserved := 0
+		for _, next := range positionals[i+1:] {
+			nextMin, _ := next.Arity()
+			reserved += nextMin
+		}
+
+		n := len(args) - reserved
+		n = max(n, min(opt.arityMin, len(args)))
+		n = min(n, opt.arityMax)
+		if n == 0 {
+			continue
+		}
+
+		p.assignments = append(p.assignments, assignment{
+			opt:    opt,
+			source: sources[0],
+			values: args[:n],
+		})
+		args = args[n:]
+		sources = sources[n:]
+	}
+
+	return args
+}
+
+// parse collects the values of all options found in args and returns the arguments
+// which were not consumed. Unless the application is POSIXLY_CORRECT, options may follow
+// positional arguments, and only "--" ends the options.
+func (p *parser) parse(args []string) ([]string, error) {
+	var err error
+	var positionals []string
+	var sources []Source
+
+	posixlyCorrect := p.app.isPosixlyCorrect()
+
+	for len(args) > 0 {
+		var rest []string
+		rest, err = p.parseOne(args)
+		if err != nil {
+			if !errors.Is(err, ErrEndOfArguments) {
+				return nil, err
+			}
+
+			// parseOne consumes "--" but not a positional argument
+			endOfOptions := len(rest) < len(args)
+			if !endOfOptions && !posixlyCorrect {
+				positionals = append(positionals, args[0])
+				sources = append(sources, p.source(args))
+				args = args[1:]
+				continue
+			}
+
+			args = rest
+			if endOfOptions && p.app.passthrough != nil {
+				p.passthrough = slices.Clone(args)
+				args = nil
+			}
+			break
+		}
+
+		args = rest
+	}
+
+	for len(args) > 0 {
+		// when POSIXLY_CORRECT ends the options at a positional argument, a later "--" still
+		// starts the passthrough arguments
+		if args[0] == "--" && p.app.passthrough != nil {
+			p.passthrough = slices.Clone(args[1:])
+			break
+		}
+
+		positionals = append(positionals, args[0])
+		sources = append(sources, p.source(args))
+		args = args[1:]
+	}
+
+	return p.parsePositional(positionals, sources), nil
+}
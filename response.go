@@ -0,0 +1,96 @@
+package cligo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/exp/slices"
+)
+
+// WithResponseFiles enables response files. When enabled, an argument of the form @path is
+// replaced by the arguments contained in the file at path, in the same way as compilers such
+// as gcc and javac. This allows command lines which would otherwise exceed the operating
+// system's limits.
+//
+// The contents of the file are split into arguments following the quoting rules of the POSIX
+// shell, and may contain comments starting with #. Response files may refer to other response
+// files, but not to themselves, directly or indirectly. Arguments following -- are not expanded.
+func WithResponseFiles() AppOption {
+	return func(app *App) {
+		app.responseFiles = true
+	}
+}
+
+// expandResponseFiles replaces response file arguments in args with their contents, and returns
+// the expanded arguments along with the source of each one. argOrigins contains the source of
+// each of args.
+func expandResponseFiles(args []string, argOrigins []Source) ([]string, []Source, error) {
+	expanded, origins, _, err := expandResponseArgs(args, argOrigins, nil)
+	return expanded, origins, err
+}
+
+// expandResponseArgs is like expandResponseFiles, where stack contains the response files
+// currently being expanded, for cycle detection. It also returns true if a -- was found, in
+// which case the arguments following the response file being expanded must not be expanded either.
+func expandResponseArgs(args []string, argOrigins []Source, stack []string) ([]string, []Source, bool, error) {
+	var expanded []string
+	var origins []Source
+
+	for i, arg := range args {
+		if arg == "--" {
+			expanded = append(expanded, args[i:]...)
+			origins = append(origins, argOrigins[i:]...)
+			return expanded, origins, true, nil
+		}
+
+		if len(arg) < 2 || arg[0] != '@' {
+			expanded = append(expanded, arg)
+			origins = append(origins, argOrigins[i])
+			continue
+		}
+
+		path := arg[1:]
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		if slices.Contains(stack, abs) {
+			return nil, nil, false, fmt.Errorf("response file %s includes itself", path)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("response file %s: %w", path, err)
+		}
+
+		words, err := SplitCommandLine(string(data))
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("response file %s: %w", path, err)
+		}
+
+		wordOrigins := make([]Source, len(words))
+		for j := range wordOrigins {
+			wordOrigins[j] = argOrigins[i]
+			wordOrigins[j].File = path
+		}
+
+		words, wordOrigins, ended, err := expandResponseArgs(words, wordOrigins, append(slices.Clip(stack), abs))
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		expanded = append(expanded, words...)
+		origins = append(origins, wordOrigins...)
+
+		// a -- in the response file ends the options, so the remaining arguments are kept as is
+		if ended {
+			expanded = append(expanded, args[i+1:]...)
+			origins = append(origins, argOrigins[i+1:]...)
+			return expanded, origins, true, nil
+		}
+	}
+
+	return expanded, origins, false, nil
+}
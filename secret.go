@@ -0,0 +1,48 @@
+package cligo
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	secretMask          = "********"
+	secretFileSuffix    = "-file"
+	secretEnvFileSuffix = "_FILE"
+)
+
+// findSecretFileOption finds the secret option whose long name is name without the "-file" suffix
+func (a App) findSecretFileOption(name string) (*Option, bool) {
+	base, found := strings.CutSuffix(name, secretFileSuffix)
+	if !found {
+		return nil, false
+	}
+
+	opt, isNegated, exists := a.findLongOption(base)
+	if !exists || isNegated || !opt.isSecret {
+		return nil, false
+	}
+
+	return opt, true
+}
+
+// maskError replaces errors caused by the value of a secret option, which may contain the
+// value, with one which doesn't
+func (opt Option) maskError(err error) error {
+	if err == nil || !opt.isSecret {
+		return err
+	}
+
+	return fmt.Errorf("%s: invalid value %s", opt.displayName(), secretMask)
+}
+
+// readSecretFile returns the contents of the file at path without trailing newlines
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,307 @@
+package cligo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SourceKind identifies where the value of an option came from.
+type SourceKind int
+
+const (
+	// SourceDefault means the option was not set, so the bound variable holds its default value.
+	SourceDefault SourceKind = iota
+
+	// SourceArgv means the option was set on the command line.
+	SourceArgv
+
+	// SourceEnv means the option was set by an environment variable.
+	SourceEnv
+
+	// SourceConfig means the option was set by a configuration file.
+	SourceConfig
+)
+
+func (k SourceKind) String() string {
+	switch k {
+	case SourceDefault:
+		return "default"
+	case SourceArgv:
+		return "argv"
+	case SourceEnv:
+		return "env"
+	case SourceConfig:
+		return "config"
+	default:
+		return fmt.Sprintf("SourceKind(%d)", int(k))
+	}
+}
+
+// Source describes where the value of an option came from.
+type Source struct {
+	Kind SourceKind
+
+	// For SourceArgv, the index of the argument in the parsed arguments (not including the
+	// program name), after arguments from WithArgsEnv and response files have been added
+	Index int
+
+	// For SourceEnv, the name of the environment variable.
+	// For SourceConfig, the name used in the configuration file.
+	// For SourceArgv, the name of the environment variable set with WithArgsEnv, if the
+	// argument came from there.
+	Name string
+
+	// For SourceConfig, the path of the configuration file.
+	// For SourceEnv, the path of the dotenv file the variable was read from, if any.
+	// For SourceArgv, the path of the response file the argument was read from, if any.
+	File string
+
+	// The line number within File
+	Line int
+}
+
+func (s Source) String() string {
+	switch s.Kind {
+	case SourceArgv:
+		if s.File != "" {
+			return fmt.Sprintf("argv[%d] from @%s", s.Index, s.File)
+		}
+		if s.Name != "" {
+			return fmt.Sprintf("argv[%d] from $%s", s.Index, s.Name)
+		}
+		return fmt.Sprintf("argv[%d]", s.Index)
+	case SourceEnv:
+		if s.File != "" {
+			return fmt.Sprintf("env %s from %s:%d", s.Name, s.File, s.Line)
+		}
+		return fmt.Sprintf("env %s", s.Name)
+	case SourceConfig:
+		return fmt.Sprintf("config %s:%d", s.File, s.Line)
+	default:
+		return s.Kind.String()
+	}
+}
+
+// wrap adds the location of the source to errors which occur while assigning its value
+func (s Source) wrap(err error) error {
+	switch s.Kind {
+	case SourceEnv:
+		if s.File != "" {
+			return fmt.Errorf("environment variable %s (%s:%d): %w", s.Name, s.File, s.Line, err)
+		}
+		return fmt.Errorf("environment variable %s: %w", s.Name, err)
+	case SourceConfig:
+		return fmt.Errorf("%s:%d: %w", s.File, s.Line, err)
+	case SourceArgv:
+		if s.File != "" {
+			return fmt.Errorf("response file %s: %w", s.File, err)
+		}
+		if s.Name != "" {
+			return fmt.Errorf("environment variable %s: %w", s.Name, err)
+		}
+		return err
+	default:
+		return err
+	}
+}
+
+// WithPrecedence sets the order in which the sources of option values are consulted, from
+// highest to lowest priority. An option takes its values from the first source in the list
+// which provides any, and lower priority sources are ignored for that option. Sources which
+// are not listed are not used at all.
+//
+// The default is:
+//
+//	WithPrecedence(SourceArgv, SourceEnv, SourceConfig, SourceDefault)
+func WithPrecedence(kinds ...SourceKind) AppOption {
+	return func(app *App) {
+		app.precedence = kinds
+	}
+}
+
+// WithLookupEnv sets the function used to read the real environment variables.
+// The default is os.LookupEnv.
+func WithLookupEnv(f func(name string) (string, bool)) AppOption {
+	return func(app *App) {
+		app.lookupEnv = f
+	}
+}
+
+// resolve assigns the values of the options selected by filter from the sources of values
+// in order of precedence.
+func (p *parser) resolve(filter func(opt *Option) bool) error {
+	a := p.app
+	claimed := make(map[*Option]bool)
+
+	for _, kind := range a.precedence {
+		var layer []assignment
+		switch kind {
+		case SourceArgv:
+			layer = p.assignments
+		case SourceEnv:
+			layer = a.envAssignments()
+		case SourceConfig:
+			entries, err := p.configEntries()
+			if err != nil {
+				return err
+			}
+
+			if layer, err = a.configAssignments(entries); err != nil {
+				return err
+			}
+		case SourceDefault:
+			layer = a.defaultAssignments()
+		}
+
+		for _, as := range layer {
+			if claimed[as.opt] || !filter(as.opt) {
+				continue
+			}
+
+			if err := as.apply(); err != nil {
+				return as.source.wrap(err)
+			}
+		}
+
+		for _, as := range layer {
+			claimed[as.opt] = true
+		}
+	}
+
+	return nil
+}
+
+// apply assigns the value to the option. Default values are assigned directly to the bound
+// variable, so they don't count as an occurrence of the option or call its trigger.
+func (as assignment) apply() error {
+	value := as.value
+	if as.fromFile {
+		contents, err := readSecretFile(value)
+		if err != nil {
+			return err
+		}
+		value = contents
+	}
+
+	values := []string{value}
+	if as.opt.hasArity() {
+		// values from sources other than the command line are separated by whitespace
+		values = as.values
+		if values == nil {
+			values = strings.Fields(value)
+		}
+
+		if err := as.opt.checkArity(len(values)); err != nil {
+			return err
+		}
+	}
+
+	if as.source.Kind == SourceDefault {
+		if as.opt.ptr != nil {
+			as.opt.clearSlice()
+			if err := setValues(as.opt.ptr, values); err != nil {
+				return as.opt.maskError(err)
+			}
+		}
+
+		as.opt.source = as.source
+		return nil
+	}
+
+	return as.opt.set(values, as.isNegated, as.source)
+}
+
+func (a App) envAssignments() []assignment {
+	var layer []assignment
+	for _, opt := range a.options {
+		for _, name := range opt.envNames {
+			if as, ok := a.envAssignment(opt, name); ok {
+				layer = append(layer, as)
+				break
+			}
+		}
+	}
+
+	return layer
+}
+
+// envAssignment returns the assignment for opt from the environment variable name, or for
+// secret options, from the file named by the environment variable name_FILE
+func (a App) envAssignment(opt *Option, name string) (assignment, bool) {
+	if value, source, ok := a.getenv(name); ok && !(opt.isFlag && value == "") {
+		return assignment{opt: opt, value: value, source: source}, true
+	}
+
+	if opt.isSecret {
+		if path, source, ok := a.getenv(name + secretEnvFileSuffix); ok && path != "" {
+			return assignment{opt: opt, value: path, source: source, fromFile: true}, true
+		}
+	}
+
+	return assignment{}, false
+}
+
+func (a App) defaultAssignments() []assignment {
+	var layer []assignment
+	for _, opt := range a.options {
+		if opt.hasDefault {
+			layer = append(layer, assignment{
+				opt:    opt,
+				value:  opt.defaultValue,
+				source: Source{Kind: SourceDefault},
+			})
+		}
+	}
+
+	return layer
+}
+
+// Source returns where the value of the option came from. If the option was set multiple
+// times, this is the last place it was set.
+func (opt Option) Source() Source {
+	return opt.source
+}
+
+// Provenance describes the current value of an option and where it came from.
+// The value of secret options is masked.
+type Provenance struct {
+	Option *Option
+	Value  string
+	Source Source
+}
+
+func (p Provenance) String() string {
+	return fmt.Sprintf("%s=%s (%s)", p.Option.displayName(), p.Value, p.Source)
+}
+
+// Provenance returns the current value of every option along with where the value came from,
+// in the order the options were added. This is useful for answering the question "why does
+// this option have this value?" when values can come from multiple places.
+func (a App) Provenance() []Provenance {
+	report := make([]Provenance, 0, len(a.options))
+	for _, opt := range a.options {
+		report = append(report, Provenance{
+			Option: opt,
+			Value:  opt.displayValue(),
+			Source: opt.source,
+		})
+	}
+
+	return report
+}
+
+// displayName returns the most descriptive name of the option, including leading dashes
+func (opt Option) displayName() string {
+	switch {
+	case len(opt.lNames) != 0:
+		return "--" + opt.lNames[0]
+	case len(opt.sNames) != 0:
+		return "-" + opt.sNames[0]
+	case len(opt.lNamesNeg) != 0:
+		return "--" + opt.lNamesNeg[0]
+	case len(opt.sNamesNeg) != 0:
+		return "-" + opt.sNamesNeg[0]
+	default:
+		return opt.pName
+	}
+}
@@ -0,0 +1,90 @@
+package cligo
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	errUnterminatedSingleQuote = errors.New("unterminated single quote")
+	errUnterminatedDoubleQuote = errors.New("unterminated double quote")
+)
+
+// SplitCommandLine splits s into arguments following the quoting rules of the POSIX shell:
+//
+//   - words are separated by unquoted whitespace, including newlines
+//   - characters inside single quotes are used literally
+//   - inside double quotes, a backslash only escapes $, `, ", \ and newline
+//   - outside of quotes, a backslash escapes any character, and a backslash followed by a
+//     newline is removed entirely
+//   - a # at the start of a word begins a comment which extends to the end of the line
+//
+// No expansion of variables, globs or commands is performed.
+func SplitCommandLine(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case ch == '#' && !inWord:
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case ch == '\\':
+			if i+1 < len(s) {
+				i++
+				if s[i] == '\n' {
+					continue
+				}
+				word.WriteByte(s[i])
+			} else {
+				word.WriteByte(ch)
+			}
+			inWord = true
+		case ch == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end == -1 {
+				return nil, errUnterminatedSingleQuote
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case ch == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					switch s[i+1] {
+					case '$', '`', '"', '\\':
+						i++
+					case '\n':
+						i++
+						continue
+					}
+				}
+				word.WriteByte(s[i])
+			}
+
+			if i >= len(s) {
+				return nil, errUnterminatedDoubleQuote
+			}
+			inWord = true
+		default:
+			word.WriteByte(ch)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
@@ -0,0 +1,313 @@
+package cligo
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"golang.org/x/exp/slices"
+)
+
+// UsageData is the data model passed to usage templates set with SetUsageTemplate.
+type UsageData struct {
+	// The name of the program, as set by WithProgramName
+	ProgramName string
+
+	// The version of the program, as set by WithVersion, or empty
+	Version string
+
+	// The usage line, for example "my_app [OPTIONS] filename"
+	Synopsis string
+
+	// The summary paragraph set with WithDescription, or empty
+	Description string
+
+	// The footer paragraph set with WithEpilog, or empty
+	Epilog string
+
+	// The positional arguments in the order they are parsed
+	Positionals []UsageOption
+
+	// The groups of non-positional options in the order they were first used. The first group
+	// is always "Options", which includes the built-in help (and version) options.
+	Groups []UsageGroup
+
+	// The examples added with AddExample
+	Examples []UsageExample
+
+	// The authors set with WithAuthors
+	Authors []string
+}
+
+// UsageGroup describes a group of options for usage templates.
+type UsageGroup struct {
+	Name    string
+	Options []UsageOption
+}
+
+// UsageOption describes a single option or positional argument for usage templates.
+type UsageOption struct {
+	// The names of the option including their leading dashes, for example "-f" and "--file",
+	// or the name of the positional argument
+	Names []string
+
+	// The placeholder for the option's value, for example TEXT, or empty
+	Metavar string
+
+	// The default value as set by DefaultString or CaptureDefault, or empty. The value is
+	// masked for secret options.
+	Default string
+
+	// The help string
+	Help string
+
+	// True if the option must be set
+	Required bool
+
+	// The environment variables which can set the option
+	Env []string
+
+	// True if the option is a flag
+	IsFlag bool
+
+	// True if the option's value may be omitted, see OptionalValue
+	OptionalValue bool
+
+	// The names, metavar, default and required marker formatted as cligo does by default,
+	// for example "-f,--file TEXT [input.txt] REQUIRED"
+	Label string
+}
+
+// UsageExample describes an example added with AddExample for usage templates.
+type UsageExample struct {
+	Args        string
+	Explanation string
+}
+
+// usageFuncs are the helper functions available to usage templates:
+//
+//   - wrap WIDTH TEXT: word wraps TEXT so that no line is longer than WIDTH where possible
+//   - indent N TEXT: indents every line of TEXT by N spaces
+//   - pad WIDTH TEXT: pads TEXT with spaces on the right to WIDTH characters
+var usageFuncs = template.FuncMap{
+	"wrap":   wrapText,
+	"indent": indentText,
+	"pad":    padText,
+}
+
+const defaultUsageTemplate = `
+{{- if .Version}}{{.ProgramName}} {{.Version}}
+
+{{end -}}
+Usage: {{.Synopsis}}
+{{- if .Description}}
+
+{{.Description}}
+{{- end}}
+{{- if .Positionals}}
+
+Positionals:
+{{- range .Positionals}}
+  {{pad 30 .Label}} {{.Help}}
+{{- end}}
+{{- end}}
+{{- range .Groups}}
+
+{{.Name}}:
+{{- range .Options}}
+  {{pad 30 .Label}} {{.Help}}
+{{- end}}
+{{- end}}
+{{- if .Examples}}
+
+Examples:
+{{- range .Examples}}
+  {{$.ProgramName}} {{.Args}}
+{{- if .Explanation}}
+      {{.Explanation}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .Authors}}
+
+Authors:
+{{- range .Authors}}
+  {{.}}
+{{- end}}
+{{- end}}
+{{- if .Epilog}}
+
+{{.Epilog}}
+{{- end}}
+`
+
+var defaultUsage = template.Must(template.New("usage").Funcs(usageFuncs).Parse(defaultUsageTemplate))
+
+// SetUsageTemplate sets a text/template used to print the usage string in place of the
+// default format. The template is executed with a UsageData value and may use the helper
+// functions wrap, indent and pad. For example:
+//
+//	{{range .Groups}}{{.Name}}:
+//	{{range .Options}}  {{pad 30 .Label}} {{.Help}}
+//	{{end}}{{end}}
+//
+// A usage function set with SetUsageFunc takes priority over the template.
+func (a *App) SetUsageTemplate(tmpl string) error {
+	t, err := template.New("usage").Funcs(usageFuncs).Parse(tmpl)
+	if err != nil {
+		return err
+	}
+
+	a.usageTemplate = t
+	return nil
+}
+
+// Usage prints the usage string for the application
+func (a App) Usage() {
+
+	if a.usageFunc != nil {
+		a.usageFunc()
+		return
+	}
+
+	tmpl := a.usageTemplate
+	if tmpl == nil {
+		tmpl = defaultUsage
+	}
+
+	if err := tmpl.Execute(a.stdout, a.UsageData()); err != nil {
+		fmt.Fprintln(a.stderr, err)
+	}
+}
+
+// UsageData returns the data used to render the usage string. It is useful for
+// custom usage functions which want to reuse cligo's view of the options.
+func (a App) UsageData() UsageData {
+	data := UsageData{
+		ProgramName: a.programName,
+		Version:     a.version,
+		Description: a.description,
+		Epilog:      a.epilog,
+		Authors:     slices.Clone(a.authors),
+	}
+
+	synopsis := []string{a.programName, "[OPTIONS]"}
+
+	helpOptions := []UsageOption{
+		builtinUsageOption("Print this help message and exit", "-h", "--help"),
+	}
+
+	if names := a.versionNames(); len(names) > 0 {
+		helpOptions = append(helpOptions, builtinUsageOption("Print version information and exit", names...))
+	}
+
+	data.Groups = append(data.Groups, UsageGroup{Name: "Options", Options: helpOptions})
+
+	for _, opt := range a.options {
+		if opt.IsPositional() {
+			if opt.arityMax > 1 {
+				synopsis = append(synopsis, opt.pName+"...")
+			} else {
+				synopsis = append(synopsis, opt.pName)
+			}
+			data.Positionals = append(data.Positionals, opt.usageOption([]string{opt.pName}))
+		}
+
+		if opt.IsPositionalOnly() {
+			continue
+		}
+
+		index := -1
+		for i, group := range data.Groups {
+			if group.Name == opt.group {
+				index = i
+				break
+			}
+		}
+
+		if index == -1 {
+			data.Groups = append(data.Groups, UsageGroup{Name: opt.group})
+			index = len(data.Groups) - 1
+		}
+
+		data.Groups[index].Options = append(data.Groups[index].Options, opt.usageOption(opt.names()))
+	}
+
+	for _, ex := range a.examples {
+		data.Examples = append(data.Examples, UsageExample{Args: ex.args, Explanation: ex.explanation})
+	}
+
+	if a.passthrough != nil {
+		synopsis = append(synopsis, "[-- "+a.passthroughName+"...]")
+	}
+
+	data.Synopsis = strings.Join(synopsis, " ")
+	return data
+}
+
+func (opt *Option) usageOption(names []string) UsageOption {
+	return UsageOption{
+		Names:    names,
+		Metavar:  opt.metavar(),
+		Default:  opt.displayDefault(),
+		Help:     opt.description,
+		Required: opt.isRequired,
+		Env:      opt.envNames,
+		IsFlag:   opt.isFlag,
+		Label:    opt.label(strings.Join(names, ",")),
+
+		OptionalValue: opt.hasImplicit,
+	}
+}
+
+func builtinUsageOption(help string, names ...string) UsageOption {
+	return UsageOption{
+		Names:  names,
+		Help:   help,
+		IsFlag: true,
+		Label:  strings.Join(names, ","),
+	}
+}
+
+func wrapText(width int, text string) string {
+	var sb strings.Builder
+	for i, paragraph := range strings.Split(text, "\n") {
+		if i != 0 {
+			sb.WriteString("\n")
+		}
+
+		lineLength := 0
+		for j, word := range strings.Fields(paragraph) {
+			if j != 0 {
+				if lineLength+1+len(word) > width {
+					sb.WriteString("\n")
+					lineLength = 0
+				} else {
+					sb.WriteString(" ")
+					lineLength++
+				}
+			}
+
+			sb.WriteString(word)
+			lineLength += len(word)
+		}
+	}
+
+	return sb.String()
+}
+
+func indentText(n int, text string) string {
+	prefix := strings.Repeat(" ", n)
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func padText(width int, text string) string {
+	return fmt.Sprintf("%-*s", width, text)
+}
@@ -0,0 +1,92 @@
+package cligo
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// WithVersion sets the version of the application. When set, the application will accept
+// -V and --version, which print the version and exit. If the application defines an option
+// named -V or --version itself, that name refers to the application's option instead.
+func WithVersion(version string) AppOption {
+	return func(app *App) {
+		app.version = version
+	}
+}
+
+// WithBuildInfoVersion is like WithVersion, but the version is taken from the build
+// information embedded in the binary by the go tool. The main module's version is used,
+// followed by the VCS revision when available. For example:
+//
+//	v1.2.3 (rev 0123456789ab, modified)
+//
+// If no build information is available, the version will be "(devel)".
+func WithBuildInfoVersion() AppOption {
+	return func(app *App) {
+		app.version = buildInfoVersion(debug.ReadBuildInfo())
+	}
+}
+
+func buildInfoVersion(info *debug.BuildInfo, ok bool) string {
+	if !ok {
+		return "(devel)"
+	}
+
+	version := info.Main.Version
+	if version == "" {
+		version = "(devel)"
+	}
+
+	revision := ""
+	modified := false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+
+	if revision == "" {
+		return version
+	}
+
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+
+	if modified {
+		return fmt.Sprintf("%s (rev %s, modified)", version, revision)
+	}
+
+	return fmt.Sprintf("%s (rev %s)", version, revision)
+}
+
+// Version returns the version of the application, or an empty string if none was set.
+func (a App) Version() string {
+	return a.version
+}
+
+func (a App) printVersion() {
+	fmt.Fprintf(a.stdout, "%s %s\n", a.programName, a.version)
+}
+
+// versionNames returns the names of the built-in version option which are not taken by one of
+// the application's options, or nil if no version was set
+func (a App) versionNames() []string {
+	if a.version == "" {
+		return nil
+	}
+
+	var names []string
+	if _, _, exists := a.findShortOption("V"); !exists {
+		names = append(names, "-V")
+	}
+
+	if _, _, exists := a.findLongOption("version"); !exists {
+		names = append(names, "--version")
+	}
+
+	return names
+}
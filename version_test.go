@@ -0,0 +1,39 @@
+package cligo
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildInfoVersionSettings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		version  string
+		settings []debug.BuildSetting
+		expected string
+	}{
+		{"v1.2.3", nil, "v1.2.3"},
+		{"", nil, "(devel)"},
+		{"v1.2.3", []debug.BuildSetting{{Key: "vcs.revision", Value: "0123456789abcdef0123"}}, "v1.2.3 (rev 0123456789ab)"},
+		{"v1.2.3", []debug.BuildSetting{{Key: "vcs.revision", Value: "abc123"}}, "v1.2.3 (rev abc123)"},
+		{"v1.2.3", []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef0123"},
+			{Key: "vcs.modified", Value: "true"},
+		}, "v1.2.3 (rev 0123456789ab, modified)"},
+		{"", []debug.BuildSetting{
+			{Key: "vcs.modified", Value: "false"},
+			{Key: "vcs.revision", Value: "0123456789abcdef0123"},
+		}, "(devel) (rev 0123456789ab)"},
+		{"v1.2.3", []debug.BuildSetting{{Key: "vcs.modified", Value: "true"}}, "v1.2.3"},
+	}
+
+	for _, test := range tests {
+		info := &debug.BuildInfo{Main: debug.Module{Version: test.version}, Settings: test.settings}
+		require.Equal(t, test.expected, buildInfoVersion(info, true))
+	}
+
+	require.Equal(t, "(devel)", buildInfoVersion(nil, false))
+}